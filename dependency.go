@@ -0,0 +1,115 @@
+package gomultitask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+var (
+	// ErrUnknownDependency is returned when task depends on task which isn't in operator
+	ErrUnknownDependency = errors.New("unknown dependency")
+	// ErrAmbiguousDependency is returned when task depends on id used by several tasks
+	ErrAmbiguousDependency = errors.New("ambiguous dependency")
+	// ErrDependencyCycle is returned when tasks depend on each other
+	ErrDependencyCycle = errors.New("dependency cycle")
+)
+
+// sortByDependencies return tasks in topological order,
+// order of independent tasks is the same like in list
+func sortByDependencies(list []*task.Task) ([]*task.Task, error) {
+	idCount := make(map[string]int)
+	for _, t := range list {
+		idCount[t.GetID()]++
+	}
+	for _, t := range list {
+		for _, depID := range t.GetDependsOn() {
+			switch idCount[depID] {
+			case 0:
+				return nil, fmt.Errorf("task ID %s depends on %s: %w", t.GetID(), depID, ErrUnknownDependency)
+			case 1:
+			default:
+				return nil, fmt.Errorf("task ID %s depends on %s: %w", t.GetID(), depID, ErrAmbiguousDependency)
+			}
+		}
+	}
+
+	started := make(map[string]bool)
+	res := make([]*task.Task, 0, len(list))
+	rest := list
+	for len(rest) > 0 {
+		next := make([]*task.Task, 0, len(rest))
+		for _, t := range rest {
+			if dependenciesStarted(t, started) {
+				res = append(res, t)
+				started[t.GetID()] = true
+				continue
+			}
+			next = append(next, t)
+		}
+		if len(next) == len(rest) {
+			return nil, fmt.Errorf("tasks %s: %w", getIDs(rest), ErrDependencyCycle)
+		}
+		rest = next
+	}
+	return res, nil
+}
+
+func dependenciesStarted(t *task.Task, started map[string]bool) bool {
+	for _, depID := range t.GetDependsOn() {
+		if !started[depID] {
+			return false
+		}
+	}
+	return true
+}
+
+func getIDs(list []*task.Task) []string {
+	res := make([]string, 0, len(list))
+	for _, t := range list {
+		res = append(res, t.GetID())
+	}
+	return res
+}
+
+// getDependencies return tasks on which t depends
+func (o *Operator) getDependencies(t *task.Task) []*task.Task {
+	tasks := o.getTaskList()
+	res := make([]*task.Task, 0)
+	for _, depID := range t.GetDependsOn() {
+		for _, dep := range tasks {
+			if dep.GetID() == depID {
+				res = append(res, dep)
+			}
+		}
+	}
+	return res
+}
+
+// getDependents return tasks which depend on t
+func (o *Operator) getDependents(t *task.Task) []*task.Task {
+	res := make([]*task.Task, 0)
+	for _, dependent := range o.getTaskList() {
+		for _, depID := range dependent.GetDependsOn() {
+			if depID == t.GetID() {
+				res = append(res, dependent)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// waitDependencies wait while all dependencies of task will be ready, return false if ctx is done earlier
+func (o *Operator) waitDependencies(ctx context.Context, t *task.Task) bool {
+	for _, dep := range o.getDependencies(t) {
+		select {
+		case <-dep.Ready():
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package gomultitask
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+type journal struct {
+	mu      sync.Mutex
+	records []string
+}
+
+func (j *journal) add(record string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.records = append(j.records, record)
+}
+
+func (j *journal) get() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]string(nil), j.records...)
+}
+
+type JournalTask struct {
+	*RestartableTask
+	journal *journal
+}
+
+func NewJournalTask(id string, j *journal, dependsOn ...string) *JournalTask {
+	return &JournalTask{
+		RestartableTask: NewRestartableTask(id, task.Config{DependsOn: dependsOn}),
+		journal:         j,
+	}
+}
+
+func (t *JournalTask) Run(ctx context.Context) error {
+	t.journal.add("run " + t.id)
+	return t.RestartableTask.Run(ctx)
+}
+
+func (t *JournalTask) Shutdown(ctx context.Context) error {
+	// give a chance to tasks which must not be waited
+	time.Sleep(10 * time.Millisecond)
+	t.journal.add("shutdown " + t.id)
+	return t.RestartableTask.Shutdown(ctx)
+}
+
+func getTasks(list ...task.Interface) []*task.Task {
+	res := make([]*task.Task, 0)
+	for _, t := range list {
+		res = append(res, task.NewFromInterface(nil, t))
+	}
+	return res
+}
+
+func TestSortByDependencies(t *testing.T) {
+	r := require.New(t)
+
+	j := &journal{}
+	list := getTasks(
+		NewJournalTask("api", j, "db", "cache"),
+		NewJournalTask("db", j),
+		NewJournalTask("worker", j, "db"),
+		NewJournalTask("cache", j),
+	)
+	sorted, err := sortByDependencies(list)
+	r.NoError(err)
+	r.Equal([]string{"db", "worker", "cache", "api"}, getIDs(sorted))
+}
+
+func TestSortByDependencies_Err(t *testing.T) {
+	r := require.New(t)
+
+	j := &journal{}
+	_, err := sortByDependencies(getTasks(NewJournalTask("api", j, "db")))
+	r.True(errors.Is(err, ErrUnknownDependency))
+
+	_, err = sortByDependencies(getTasks(NewJournalTask("api", j, "db"), NewJournalTask("db", j), NewJournalTask("db", j)))
+	r.True(errors.Is(err, ErrAmbiguousDependency))
+
+	_, err = sortByDependencies(getTasks(
+		NewJournalTask("api", j, "db"),
+		NewJournalTask("db", j, "cache"),
+		NewJournalTask("cache", j, "api"),
+		NewJournalTask("worker", j),
+	))
+	r.True(errors.Is(err, ErrDependencyCycle))
+	r.Equal("tasks [api db cache]: dependency cycle", err.Error())
+}
+
+func TestNewOperator_DependencyCycle(t *testing.T) {
+	r := require.New(t)
+
+	j := &journal{}
+	r.Panics(func() {
+		NewOperator(NewJournalTask("api", j, "db"), NewJournalTask("db", j, "api"))
+	})
+}
+
+func TestOperator_DependencyOrder(t *testing.T) {
+	r := require.New(t)
+
+	j := &journal{}
+	op := NewOperator(NewJournalTask("api", j, "db"), NewJournalTask("db", j))
+	r.Equal([]string{"db", "api"}, getIDs(op.tasks))
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	waitFor(t, func() bool {
+		return len(j.get()) == 2
+	})
+	go func() {
+		op.sigCh <- syscall.SIGTERM
+	}()
+	select {
+	case err := <-tCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("Not shutdowned in expected time")
+	}
+	r.ElementsMatch([]string{"run db", "run api"}, j.get()[:2])
+	r.Equal([]string{"shutdown api", "shutdown db"}, j.get()[2:])
+}
@@ -0,0 +1,100 @@
+package gomultitask
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime/pprof"
+	"strings"
+	"time"
+)
+
+// WithDiagnosticSignals set signals which write diagnostic dump without shutdown, by default they aren't caught,
+// for example WithDiagnosticSignals([]os.Signal{syscall.SIGUSR1}).
+// Diagnostic signals are excluded from shutdown signals, so SIGQUIT can be reassigned to dump
+func (o *Operator) WithDiagnosticSignals(signals []os.Signal) *Operator {
+	o.diagnosticSignals = signals
+	return o
+}
+
+// WithDiagnosticWriter set writer for diagnostic dump by signal, by default dump is written to logger
+func (o *Operator) WithDiagnosticWriter(w io.Writer) *Operator {
+	o.diagnosticWriter = w
+	return o
+}
+
+// WriteDiagnostics write state, falls, restarts, uptime and last error of each task
+// and stacks of all goroutines to w
+func (o *Operator) WriteDiagnostics(w io.Writer) error {
+	header := "operator diagnostics"
+	if o.id != "" {
+		header = fmt.Sprintf("operator %s diagnostics", o.id)
+	}
+	if _, err := fmt.Fprintf(w, "%s at %s\n", header, time.Now().Format(time.RFC3339Nano)); err != nil {
+		return err
+	}
+	for _, t := range o.getTaskList() {
+		s := t.GetState().Snapshot()
+		line := fmt.Sprintf("task %s: status=%s ready=%t falls=%d restarts=%d uptime=%s",
+			t.GetID(), s.Status, s.Ready, s.FallNumber, s.RestartNumber, s.Uptime)
+		if s.LastError != nil {
+			line += fmt.Sprintf(" last_error_at=%s last_error=%q", s.LastErrorAt.Format(time.RFC3339Nano), s.LastError)
+		}
+		if !s.RestartAt.IsZero() {
+			line += fmt.Sprintf(" restart_at=%s", s.RestartAt.Format(time.RFC3339Nano))
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintln(w, "goroutines:"); err != nil {
+		return err
+	}
+	return pprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+// writeDiagnostics write diagnostic dump to diagnostic writer or to logger
+func (o *Operator) writeDiagnostics() {
+	if o.diagnosticWriter != nil {
+		if err := o.WriteDiagnostics(o.diagnosticWriter); err != nil {
+			o.logError("Diagnostic dump isn't written", LogKeyError, err)
+		}
+		return
+	}
+	var sb strings.Builder
+	_ = o.WriteDiagnostics(&sb)
+	o.logInfo("Diagnostic dump", LogKeyDump, sb.String())
+}
+
+// handleDiagnosticSignals write diagnostic dump on diagnostic signals while ctx isn't done
+func (o *Operator) handleDiagnosticSignals(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-o.diagnosticCh:
+			o.logInfo("Diagnostic signal caught", LogKeySignal, sig.String())
+			o.events.publish(Event{Type: EventDiagnosticDump, Signal: sig})
+			o.writeDiagnostics()
+		}
+	}
+}
+
+// getShutdownSignals return shutdown signals except diagnostic ones
+func (o *Operator) getShutdownSignals() []os.Signal {
+	res := make([]os.Signal, 0, len(o.shutdownSignals))
+	for _, sig := range o.shutdownSignals {
+		diagnostic := false
+		for _, diagnosticSig := range o.diagnosticSignals {
+			if sig == diagnosticSig {
+				diagnostic = true
+				break
+			}
+		}
+		if !diagnostic {
+			res = append(res, sig)
+		}
+	}
+	return res
+}
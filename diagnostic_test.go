@@ -0,0 +1,76 @@
+package gomultitask
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+func TestOperator_WriteDiagnostics(t *testing.T) {
+	r := require.New(t)
+
+	failing := NewRestartableTask("failing", task.Config{FallNumber: 3})
+	op := NewOperator(NewRestartableTask("first", task.Config{}), failing).WithID("root").WithShutdownSignals(nil)
+	runOperator(t, op)
+
+	failing.failCh <- errors.New("expected error")
+	waitFor(t, func() bool {
+		return failing.getRunCount() == 2
+	})
+
+	var buf bytes.Buffer
+	r.NoError(op.WriteDiagnostics(&buf))
+	dump := buf.String()
+	r.Contains(dump, "operator root diagnostics at ")
+	r.Contains(dump, "task first: status=running ready=true falls=0 restarts=0 uptime=")
+	r.Contains(dump, "task failing: status=running ready=true falls=1 restarts=1 uptime=")
+	r.Contains(dump, `last_error="expected error"`)
+	r.Contains(dump, "goroutines:\ngoroutine ")
+	r.Contains(dump, "TestOperator_WriteDiagnostics")
+}
+
+func TestOperator_DiagnosticSignal(t *testing.T) {
+	r := require.New(t)
+
+	var buf lockedBuffer
+	op := NewOperator(NewRestartableTask("first", task.Config{})).
+		WithShutdownSignals([]os.Signal{syscall.SIGTERM, syscall.SIGQUIT}).
+		WithDiagnosticSignals([]os.Signal{syscall.SIGQUIT}).
+		WithDiagnosticWriter(&buf)
+	r.Equal([]os.Signal{syscall.SIGTERM}, op.getShutdownSignals())
+	runOperator(t, op)
+
+	// operator isn't stopped by diagnostic signal
+	r.NoError(syscall.Kill(syscall.Getpid(), syscall.SIGQUIT))
+	waitFor(t, func() bool {
+		return strings.Contains(buf.String(), "goroutines:")
+	})
+	r.Contains(buf.String(), "task first: status=running")
+	r.True(op.IsLive())
+}
+
+func TestOperator_DiagnosticSignal_Logger(t *testing.T) {
+	r := require.New(t)
+
+	var buf lockedBuffer
+	op := NewOperator(NewRestartableTask("first", task.Config{})).
+		WithShutdownSignals(nil).
+		WithDiagnosticSignals([]os.Signal{syscall.SIGUSR1}).
+		WithSlog(slog.New(slog.NewTextHandler(&buf, nil)))
+	runOperator(t, op)
+
+	r.NoError(syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	waitFor(t, func() bool {
+		return strings.Contains(buf.String(), "msg=\"Diagnostic dump\"")
+	})
+	r.Contains(buf.String(), "msg=\"Diagnostic signal caught\" signal=\"user defined signal 1\"")
+	r.Contains(buf.String(), "task first: status=running")
+}
@@ -0,0 +1,178 @@
+package gomultitask
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Exit codes returned by ExitCode
+const (
+	ExitCodeOK               = 0
+	ExitCodeTaskFailed       = 1
+	ExitCodeShutdownFailed   = 2
+	ExitCodeShutdownDeadline = 3
+	ExitCodeForced           = 4
+)
+
+// ErrShutdownDeadline is reason of error when deadline for graceful shutdown is reached
+var ErrShutdownDeadline = errors.New("deadline for graceful shutdown is reached")
+
+// ErrOperatorStopped is cause of cancel of tasks context when operator is stopped
+var ErrOperatorStopped = errors.New("operator is stopped")
+
+// StopReason describes why operator started shutdown
+type StopReason int
+
+const (
+	// StopReasonSignal - shutdown signal was caught
+	StopReasonSignal StopReason = iota + 1
+	// StopReasonShutdown - Shutdown was called
+	StopReasonShutdown
+	// StopReasonTaskFailed - task reached fall limit
+	StopReasonTaskFailed
+	// StopReasonContextDone - context passed to Run was canceled or its deadline was reached
+	StopReasonContextDone
+	// StopReasonCompleted - task with CompletionStop policy or all tasks completed successfully
+	StopReasonCompleted
+)
+
+// String return name of reason
+func (r StopReason) String() string {
+	switch r {
+	case StopReasonSignal:
+		return "signal"
+	case StopReasonShutdown:
+		return "shutdown"
+	case StopReasonTaskFailed:
+		return "task failed"
+	case StopReasonContextDone:
+		return "context done"
+	case StopReasonCompleted:
+		return "completed"
+	default:
+		return "unknown"
+	}
+}
+
+// ShutdownError is error returned by Shutdown of task
+type ShutdownError struct {
+	TaskID string
+	Err    error
+}
+
+// Error implements error
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("shutdown task ID %s: %s", e.TaskID, e.Err.Error())
+}
+
+// Unwrap return error of task
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+// StopError is returned by Operator.Run, it describes why operator stopped
+// and what was wrong while graceful shutdown
+type StopError struct {
+	Reason StopReason
+	// caught signal for StopReasonSignal
+	Signal os.Signal
+	// id and last error of task for StopReasonTaskFailed,
+	// id of completed task for StopReasonCompleted, it's empty if all tasks completed
+	TaskID  string
+	TaskErr error
+	// cause of done of context passed to Run for StopReasonContextDone
+	Cause error
+	// errors returned by Shutdown of tasks
+	ShutdownErrs []*ShutdownError
+	// true if deadline for graceful shutdown was reached,
+	// UnfinishedTasks contains ids of tasks which weren't stopped in time
+	DeadlineExceeded bool
+	// true if graceful shutdown was forced by signal, UnfinishedTasks contains ids of tasks which weren't stopped
+	Forced          bool
+	UnfinishedTasks []string
+}
+
+// Error implements error
+func (e *StopError) Error() string {
+	parts := make([]string, 0)
+	switch e.Reason {
+	case StopReasonSignal:
+		parts = append(parts, fmt.Sprintf("operator stopped by signal %s", e.Signal))
+	case StopReasonTaskFailed:
+		parts = append(parts, fmt.Sprintf("operator stopped by failed task ID %s: %s", e.TaskID, e.TaskErr))
+	case StopReasonContextDone:
+		parts = append(parts, fmt.Sprintf("operator stopped by done of context: %s", e.Cause))
+	case StopReasonCompleted:
+		if e.TaskID == "" {
+			parts = append(parts, "operator stopped by completion of all tasks")
+			break
+		}
+		parts = append(parts, fmt.Sprintf("operator stopped by completion of task ID %s", e.TaskID))
+	default:
+		parts = append(parts, fmt.Sprintf("operator stopped by %s", e.Reason))
+	}
+	for _, err := range e.ShutdownErrs {
+		parts = append(parts, err.Error())
+	}
+	if e.DeadlineExceeded {
+		parts = append(parts, fmt.Sprintf("%s, unfinished tasks %v", ErrShutdownDeadline, e.UnfinishedTasks))
+	}
+	if e.Forced {
+		parts = append(parts, fmt.Sprintf("%s, unfinished tasks %v", ErrForcedShutdown, e.UnfinishedTasks))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Unwrap return all errors, it's used by errors.Is and errors.As,
+// multiple wrapped errors are supported by them since Go 1.20
+func (e *StopError) Unwrap() []error {
+	res := make([]error, 0, len(e.ShutdownErrs)+3)
+	if e.TaskErr != nil {
+		res = append(res, e.TaskErr)
+	}
+	if e.Cause != nil {
+		res = append(res, e.Cause)
+	}
+	for _, err := range e.ShutdownErrs {
+		res = append(res, err)
+	}
+	if e.DeadlineExceeded {
+		res = append(res, ErrShutdownDeadline)
+	}
+	if e.Forced {
+		res = append(res, ErrForcedShutdown)
+	}
+	return res
+}
+
+// isClean return true if operator was stopped by request and shutdown hadn't errors,
+// stop by done of context isn't clean, so caller can check it by errors.Is(err, context.Canceled)
+func (e *StopError) isClean() bool {
+	return e.Reason != StopReasonContextDone && e.TaskErr == nil && len(e.ShutdownErrs) == 0 &&
+		!e.DeadlineExceeded && !e.Forced
+}
+
+// ExitCode map error returned by Operator.Run to exit code of process
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeOK
+	}
+	var stopErr *StopError
+	if !errors.As(err, &stopErr) {
+		return ExitCodeTaskFailed
+	}
+	switch {
+	case stopErr.TaskErr != nil:
+		return ExitCodeTaskFailed
+	case stopErr.Forced:
+		return ExitCodeForced
+	case stopErr.DeadlineExceeded:
+		return ExitCodeShutdownDeadline
+	case len(stopErr.ShutdownErrs) > 0:
+		return ExitCodeShutdownFailed
+	default:
+		return ExitCodeOK
+	}
+}
@@ -0,0 +1,82 @@
+package gomultitask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStopReason_String(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("signal", StopReasonSignal.String())
+	r.Equal("shutdown", StopReasonShutdown.String())
+	r.Equal("task failed", StopReasonTaskFailed.String())
+	r.Equal("context done", StopReasonContextDone.String())
+	r.Equal("completed", StopReasonCompleted.String())
+	r.Equal("unknown", StopReason(0).String())
+}
+
+func TestStopError(t *testing.T) {
+	r := require.New(t)
+
+	taskErr := errors.New("task error")
+	shutdownErr := errors.New("shutdown error")
+	err := &StopError{
+		Reason:           StopReasonTaskFailed,
+		TaskID:           "first",
+		TaskErr:          taskErr,
+		ShutdownErrs:     []*ShutdownError{{TaskID: "second", Err: shutdownErr}},
+		DeadlineExceeded: true,
+		UnfinishedTasks:  []string{"third"},
+	}
+	r.EqualError(
+		err,
+		"operator stopped by failed task ID first: task error; "+
+			"shutdown task ID second: shutdown error; "+
+			"deadline for graceful shutdown is reached, unfinished tasks [third]",
+	)
+	wrapped := fmt.Errorf("wrapped: %w", err)
+	r.True(errors.Is(wrapped, taskErr))
+	r.True(errors.Is(wrapped, shutdownErr))
+	r.True(errors.Is(wrapped, ErrShutdownDeadline))
+	var sErr *ShutdownError
+	r.True(errors.As(wrapped, &sErr))
+	r.Equal("second", sErr.TaskID)
+
+	r.EqualError(&StopError{Reason: StopReasonSignal, Signal: syscall.SIGINT}, "operator stopped by signal interrupt")
+	r.EqualError(&StopError{Reason: StopReasonShutdown}, "operator stopped by shutdown")
+
+	ctxErr := &StopError{Reason: StopReasonContextDone, Cause: context.Canceled}
+	r.EqualError(ctxErr, "operator stopped by done of context: context canceled")
+	r.True(errors.Is(ctxErr, context.Canceled))
+	r.False(ctxErr.isClean())
+
+	forcedErr := &StopError{Reason: StopReasonSignal, Signal: syscall.SIGINT, Forced: true, UnfinishedTasks: []string{"first"}}
+	r.EqualError(forcedErr, "operator stopped by signal interrupt; graceful shutdown is forced, unfinished tasks [first]")
+	r.True(errors.Is(forcedErr, ErrForcedShutdown))
+	r.False(forcedErr.isClean())
+
+	r.EqualError(&StopError{Reason: StopReasonCompleted}, "operator stopped by completion of all tasks")
+	r.EqualError(&StopError{Reason: StopReasonCompleted, TaskID: "first"}, "operator stopped by completion of task ID first")
+}
+
+func TestExitCode(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal(ExitCodeOK, ExitCode(nil))
+	r.Equal(ExitCodeTaskFailed, ExitCode(errors.New("unknown error")))
+	r.Equal(ExitCodeTaskFailed, ExitCode(&StopError{TaskErr: errors.New("task error"), DeadlineExceeded: true}))
+	r.Equal(ExitCodeShutdownDeadline, ExitCode(&StopError{DeadlineExceeded: true}))
+	r.Equal(ExitCodeForced, ExitCode(&StopError{Forced: true, DeadlineExceeded: true}))
+	r.Equal(
+		ExitCodeShutdownFailed,
+		ExitCode(fmt.Errorf("wrapped: %w", &StopError{ShutdownErrs: []*ShutdownError{{Err: errors.New("err")}}})),
+	)
+	r.Equal(ExitCodeOK, ExitCode(&StopError{Reason: StopReasonSignal}))
+	r.Equal(ExitCodeOK, ExitCode(&StopError{Reason: StopReasonContextDone, Cause: context.Canceled}))
+}
@@ -0,0 +1,174 @@
+package gomultitask
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+// EventType is type of lifecycle event of operator and its tasks
+type EventType int
+
+const (
+	// EventTaskStarting - run attempt of task is starting
+	EventTaskStarting EventType = iota + 1
+	// EventTaskReady - task became ready
+	EventTaskReady
+	// EventTaskFailed - run attempt of task returned error
+	EventTaskFailed
+	// EventTaskRestarting - task will be run again after Delay
+	EventTaskRestarting
+	// EventTaskStopped - task won't be run again
+	EventTaskStopped
+	// EventShutdownStarted - graceful shutdown of operator is started
+	EventShutdownStarted
+	// EventShutdownCompleted - graceful shutdown of operator is completed, Err is result of Run,
+	// it's published when Shutdown of tasks is returned, so EventTaskStopped of task can follow it
+	EventShutdownCompleted
+	// EventSignalReceived - operator caught signal
+	EventSignalReceived
+	// EventShutdownEscalated - operator caught signal while graceful shutdown, Stage is action of operator
+	EventShutdownEscalated
+	// EventReloadStarted - operator caught reload signal
+	EventReloadStarted
+	// EventReloadCompleted - reload of tasks is completed, Err contains joined errors of tasks
+	EventReloadCompleted
+	// EventDiagnosticDump - operator caught diagnostic signal and writes dump
+	EventDiagnosticDump
+)
+
+// String return name of event type
+func (t EventType) String() string {
+	switch t {
+	case EventTaskStarting:
+		return "task_starting"
+	case EventTaskReady:
+		return "task_ready"
+	case EventTaskFailed:
+		return "task_failed"
+	case EventTaskRestarting:
+		return "task_restarting"
+	case EventTaskStopped:
+		return "task_stopped"
+	case EventShutdownStarted:
+		return "shutdown_started"
+	case EventShutdownCompleted:
+		return "shutdown_completed"
+	case EventSignalReceived:
+		return "signal_received"
+	case EventShutdownEscalated:
+		return "shutdown_escalated"
+	case EventReloadStarted:
+		return "reload_started"
+	case EventReloadCompleted:
+		return "reload_completed"
+	case EventDiagnosticDump:
+		return "diagnostic_dump"
+	default:
+		return "unknown"
+	}
+}
+
+// Event of operator lifecycle, fields are filled if they make sense for type of event
+type Event struct {
+	Type       EventType
+	Time       time.Time
+	TaskID     string
+	FallNumber int
+	Err        error
+	Delay      time.Duration
+	Signal     os.Signal
+	Stage      EscalationStage
+}
+
+var taskEventTypes = map[task.EventType]EventType{
+	task.EventStarting:   EventTaskStarting,
+	task.EventReady:      EventTaskReady,
+	task.EventFailed:     EventTaskFailed,
+	task.EventRestarting: EventTaskRestarting,
+	task.EventStopped:    EventTaskStopped,
+}
+
+// eventBus delivers events to subscribers without blocking of publisher,
+// events are dropped for subscribers with full buffer
+type eventBus struct {
+	mu          sync.RWMutex
+	nextID      int
+	subscribers map[int]chan Event
+	dropped     int64
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{
+		subscribers: make(map[int]chan Event),
+	}
+}
+
+func (b *eventBus) subscribe(buffer int) (<-chan Event, func()) {
+	if buffer < 0 {
+		buffer = 0
+	}
+	ch := make(chan Event, buffer)
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = ch
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, id)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (b *eventBus) publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			atomic.AddInt64(&b.dropped, 1)
+		}
+	}
+}
+
+// Subscribe return channel of lifecycle events and function for unsubscribe,
+// events are dropped if buffer of subscriber is full, so slow subscriber doesn't block operator.
+// Run of task can return after EventShutdownCompleted, so events of tasks can follow it
+func (o *Operator) Subscribe(buffer int) (<-chan Event, func()) {
+	return o.events.subscribe(buffer)
+}
+
+// GetDroppedEventsNumber return number of events which weren't delivered to subscribers with full buffer
+func (o *Operator) GetDroppedEventsNumber() int64 {
+	return atomic.LoadInt64(&o.events.dropped)
+}
+
+func (o *Operator) handleTaskEvent(event task.Event) {
+	o.logDebug(
+		"Task "+event.Type.String(),
+		LogKeyTaskID, event.ID,
+		LogKeyFallNumber, event.FallNumber,
+		LogKeyState, event.Type.String(),
+	)
+	o.events.publish(Event{
+		Type:       taskEventTypes[event.Type],
+		Time:       event.Time,
+		TaskID:     event.ID,
+		FallNumber: event.FallNumber,
+		Err:        event.Err,
+		Delay:      event.Delay,
+	})
+}
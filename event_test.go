@@ -0,0 +1,116 @@
+package gomultitask
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+func TestEventType_String(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("task_starting", EventTaskStarting.String())
+	r.Equal("task_ready", EventTaskReady.String())
+	r.Equal("task_failed", EventTaskFailed.String())
+	r.Equal("task_restarting", EventTaskRestarting.String())
+	r.Equal("task_stopped", EventTaskStopped.String())
+	r.Equal("shutdown_started", EventShutdownStarted.String())
+	r.Equal("shutdown_completed", EventShutdownCompleted.String())
+	r.Equal("signal_received", EventSignalReceived.String())
+	r.Equal("unknown", EventType(0).String())
+}
+
+func TestEventBus(t *testing.T) {
+	r := require.New(t)
+
+	bus := newEventBus()
+	first, unsubscribeFirst := bus.subscribe(1)
+	second, unsubscribeSecond := bus.subscribe(2)
+	bus.publish(Event{Type: EventTaskStarting})
+	bus.publish(Event{Type: EventTaskReady})
+	r.Equal(int64(1), bus.dropped)
+
+	event := <-first
+	r.Equal(EventTaskStarting, event.Type)
+	r.False(event.Time.IsZero())
+	r.Equal(EventTaskStarting, (<-second).Type)
+	r.Equal(EventTaskReady, (<-second).Type)
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	_, ok := <-first
+	r.False(ok)
+	bus.publish(Event{Type: EventTaskStopped})
+	r.Equal(EventTaskStopped, (<-second).Type)
+	unsubscribeSecond()
+	r.Empty(bus.subscribers)
+}
+
+func TestOperator_Subscribe(t *testing.T) {
+	r := require.New(t)
+
+	first := NewRestartableTask("first", task.Config{FallNumber: 1})
+	op := NewOperator(first)
+	events, unsubscribe := op.Subscribe(100)
+	defer unsubscribe()
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	eErr := errors.New("expected error")
+	first.failCh <- eErr
+	waitFor(t, func() bool {
+		return first.getRunCount() == 2
+	})
+	go func() {
+		op.sigCh <- syscall.SIGTERM
+	}()
+	select {
+	case err := <-tCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("Not shutdowned in expected time")
+	}
+
+	types := make([]EventType, 0)
+	var stopped, completed bool
+	for !stopped || !completed {
+		select {
+		case event := <-events:
+			types = append(types, event.Type)
+			switch event.Type {
+			case EventTaskFailed:
+				r.Equal("first", event.TaskID)
+				r.Equal(1, event.FallNumber)
+				r.Equal(eErr, event.Err)
+			case EventSignalReceived:
+				r.Equal(syscall.SIGTERM, event.Signal)
+			case EventShutdownCompleted:
+				r.NoError(event.Err)
+				completed = true
+			case EventTaskStopped:
+				stopped = true
+			}
+		case <-time.After(time.Second):
+			r.Fail("Not get events in time")
+		}
+	}
+	r.Equal([]EventType{
+		EventTaskStarting,
+		EventTaskReady,
+		EventTaskFailed,
+		EventTaskRestarting,
+		EventTaskStarting,
+		EventTaskReady,
+		EventSignalReceived,
+		EventShutdownStarted,
+	}, types[:8])
+	// task can be stopped a bit later than shutdown is completed
+	r.ElementsMatch([]EventType{EventShutdownCompleted, EventTaskStopped}, types[8:])
+}
@@ -0,0 +1,207 @@
+package gomultitask
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+const (
+	healthStatusOK   = "ok"
+	healthStatusFail = "fail"
+
+	defaultHealthServerID = "health"
+)
+
+// TaskStatus is status of task in health report
+type TaskStatus struct {
+	ID             string     `json:"id"`
+	State          string     `json:"state"`
+	StateChangedAt time.Time  `json:"state_changed_at"`
+	Ready          bool       `json:"ready"`
+	FallNumber     int        `json:"fall_number"`
+	RestartNumber  int        `json:"restart_number"`
+	Uptime         string     `json:"uptime"`
+	LastErrorAt    *time.Time `json:"last_error_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	RestartAt      *time.Time `json:"restart_at,omitempty"`
+}
+
+// HealthReport is body of health endpoints
+type HealthReport struct {
+	Status string       `json:"status"`
+	Live   bool         `json:"live"`
+	Ready  bool         `json:"ready"`
+	Tasks  []TaskStatus `json:"tasks"`
+}
+
+// IsLive return true if there aren't failed tasks
+func (o *Operator) IsLive() bool {
+	for _, t := range o.getTaskList() {
+		if t.GetState().IsFailed() {
+			return false
+		}
+	}
+	return true
+}
+
+// GetHealthReport return current health of operator and its tasks
+func (o *Operator) GetHealthReport() HealthReport {
+	tasks := o.getTaskList()
+	report := HealthReport{
+		Live:  o.IsLive(),
+		Ready: o.IsReady(),
+		Tasks: make([]TaskStatus, 0, len(tasks)),
+	}
+	for _, t := range tasks {
+		snapshot := t.GetState().Snapshot()
+		status := TaskStatus{
+			ID:             t.GetID(),
+			State:          snapshot.Status.String(),
+			StateChangedAt: snapshot.ChangedAt,
+			Ready:          snapshot.Ready,
+			FallNumber:     snapshot.FallNumber,
+			RestartNumber:  snapshot.RestartNumber,
+			Uptime:         snapshot.Uptime.String(),
+		}
+		if !snapshot.LastErrorAt.IsZero() {
+			status.LastErrorAt = &snapshot.LastErrorAt
+		}
+		if snapshot.LastError != nil {
+			status.LastError = snapshot.LastError.Error()
+		}
+		if !snapshot.RestartAt.IsZero() {
+			status.RestartAt = &snapshot.RestartAt
+		}
+		report.Tasks = append(report.Tasks, status)
+	}
+	return report
+}
+
+// LivenessHandler return handler for liveness probe, it responds 503 if some task is failed
+func (o *Operator) LivenessHandler() http.Handler {
+	return o.healthHandler(func(report HealthReport) bool {
+		return report.Live
+	})
+}
+
+// ReadinessHandler return handler for readiness probe, it responds 503 if some task isn't ready,
+// completed tasks don't affect readiness
+func (o *Operator) ReadinessHandler() http.Handler {
+	return o.healthHandler(func(report HealthReport) bool {
+		return report.Ready
+	})
+}
+
+// HealthHandler return handler with /healthz and /livez for liveness and /readyz for readiness,
+// response body is JSON HealthReport
+func (o *Operator) HealthHandler() http.Handler {
+	mux := http.NewServeMux()
+	live := o.LivenessHandler()
+	mux.Handle("/healthz", live)
+	mux.Handle("/livez", live)
+	mux.Handle("/readyz", o.ReadinessHandler())
+	return mux
+}
+
+func (o *Operator) healthHandler(isOK func(HealthReport) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		report := o.GetHealthReport()
+		code := http.StatusOK
+		report.Status = healthStatusOK
+		if !isOK(report) {
+			code = http.StatusServiceUnavailable
+			report.Status = healthStatusFail
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// WithHealthServer add task with id "health" which serves HealthHandler on addr,
+// it panics if operator already has task with this id
+func (o *Operator) WithHealthServer(addr string) *Operator {
+	handler := o.HealthHandler()
+	newServer := func() *http.Server {
+		return &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+	}
+	err := o.AddTask(NewHTTPServerTask(defaultHealthServerID, newServer, task.Config{FallNumber: -1, RestartTimeout: time.Second}))
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
+
+// HTTPServerTask is task which runs http server
+type HTTPServerTask struct {
+	id        string
+	cfg       task.Config
+	newServer func() *http.Server
+
+	mu  sync.Mutex
+	srv *http.Server
+}
+
+// NewHTTPServerTask build task for http server, newServer is called for each run,
+// because http.Server can't be reused after shutdown
+func NewHTTPServerTask(id string, newServer func() *http.Server, cfg task.Config) *HTTPServerTask {
+	return &HTTPServerTask{
+		id:        id,
+		cfg:       cfg,
+		newServer: newServer,
+	}
+}
+
+// Run implements task.Interface, server is closed when context of run attempt is done
+func (t *HTTPServerTask) Run(ctx context.Context) error {
+	srv := t.newServer()
+	t.mu.Lock()
+	t.srv = srv
+	t.mu.Unlock()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		_ = srv.Close()
+		err = <-errCh
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown implements task.Interface, it gracefully stops server of current run
+func (t *HTTPServerTask) Shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	srv := t.srv
+	t.mu.Unlock()
+	if srv == nil {
+		return nil
+	}
+	return srv.Shutdown(ctx)
+}
+
+// GetTaskConfig implements task.Interface
+func (t *HTTPServerTask) GetTaskConfig() task.Config {
+	return t.cfg
+}
+
+// GetID implements task.Interface
+func (t *HTTPServerTask) GetID() string {
+	return t.id
+}
@@ -0,0 +1,192 @@
+package gomultitask
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+func getHealthReport(t *testing.T, handler http.Handler, path string) (int, HealthReport) {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var report HealthReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	return rec.Code, report
+}
+
+func TestOperator_HealthHandler(t *testing.T) {
+	r := require.New(t)
+
+	first := NewRestartableTask("first", task.Config{})
+	second := NewRestartableTask("second", task.Config{})
+	op := NewOperator(first, second).WithShutdownSignals(nil)
+	handler := op.HealthHandler()
+
+	code, report := getHealthReport(t, handler, "/readyz")
+	r.Equal(http.StatusServiceUnavailable, code)
+	r.Equal(healthStatusFail, report.Status)
+	r.False(report.Ready)
+	code, report = getHealthReport(t, handler, "/livez")
+	r.Equal(http.StatusOK, code)
+	r.Equal(healthStatusOK, report.Status)
+	r.Len(report.Tasks, 2)
+	r.Equal("pending", report.Tasks[0].State)
+
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	waitFor(t, op.IsReady)
+
+	code, report = getHealthReport(t, handler, "/readyz")
+	r.Equal(http.StatusOK, code)
+	r.True(report.Ready)
+	r.True(report.Live)
+	r.Equal("first", report.Tasks[0].ID)
+	r.Equal("running", report.Tasks[0].State)
+	r.True(report.Tasks[0].Ready)
+	r.Nil(report.Tasks[0].LastErrorAt)
+
+	first.failCh <- errors.New("expected error")
+	select {
+	case err := <-tCh:
+		r.Error(err)
+	case <-time.After(time.Second):
+		r.Fail("operator isn't stopped")
+	}
+
+	code, report = getHealthReport(t, handler, "/healthz")
+	r.Equal(http.StatusServiceUnavailable, code)
+	r.False(report.Live)
+	r.Equal("failed", report.Tasks[0].State)
+	r.Equal(1, report.Tasks[0].FallNumber)
+	r.NotNil(report.Tasks[0].LastErrorAt)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/unknown", nil))
+	r.Equal(http.StatusNotFound, rec.Code)
+}
+
+func TestOperator_WithHealthServer(t *testing.T) {
+	r := require.New(t)
+
+	op := NewOperator(NewRestartableTask("first", task.Config{})).WithHealthServer("127.0.0.1:0")
+	r.Equal([]string{"first", defaultHealthServerID}, getIDs(op.tasks))
+}
+
+func TestOperator_WithHealthServer_Restart(t *testing.T) {
+	r := require.New(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	addr := ln.Addr().String()
+	r.NoError(ln.Close())
+
+	first := NewRestartableTask("first", task.Config{FallNumber: -1})
+	op := NewOperator(first).WithShutdownSignals(nil).WithStrategy(StrategyOneForAll).WithHealthServer(addr)
+	runOperator(t, op)
+
+	// health server is restarted after fall of sibling
+	first.failCh <- errors.New("expected error")
+	waitFor(t, func() bool {
+		return first.getRunCount() == 2 && op.GetTaskSnapshots()[defaultHealthServerID].RestartNumber == 1
+	})
+	waitFor(t, func() bool {
+		resp, err := http.Get(fmt.Sprintf("http://%s/livez", addr))
+		if err != nil {
+			return false
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	})
+	r.Equal(task.StatusRunning, op.GetTaskSnapshots()[defaultHealthServerID].Status)
+}
+
+func TestOperator_ReadinessHandler_CompletedTask(t *testing.T) {
+	r := require.New(t)
+
+	migration := NewRestartableTask("migration", task.Config{})
+	op := NewOperator(migration, NewRestartableTask("server", task.Config{})).WithShutdownSignals(nil)
+	runOperator(t, op)
+
+	// one-shot task is completed successfully
+	migration.failCh <- nil
+	waitFor(t, func() bool {
+		return op.GetTaskSnapshots()["migration"].Status == task.StatusStopped
+	})
+	r.True(op.IsReady())
+	code, report := getHealthReport(t, op.HealthHandler(), "/readyz")
+	r.Equal(http.StatusOK, code)
+	r.True(report.Ready)
+	r.Equal("stopped", report.Tasks[0].State)
+	r.False(report.Tasks[0].Ready)
+}
+
+func TestHTTPServerTask(t *testing.T) {
+	r := require.New(t)
+
+	newServer := func() *http.Server {
+		return &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}
+	}
+	st := NewHTTPServerTask("http", newServer, task.Config{FallNumber: 2})
+	r.Equal("http", st.GetID())
+	r.Equal(task.Config{FallNumber: 2}, st.GetTaskConfig())
+
+	tCh := make(chan error)
+	go func() {
+		tCh <- st.Run(context.Background())
+	}()
+	time.Sleep(50 * time.Millisecond)
+	r.NoError(st.Shutdown(context.Background()))
+	select {
+	case err := <-tCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("server isn't stopped")
+	}
+}
+
+func TestOperator_HealthHandler_WaitingRestart(t *testing.T) {
+	r := require.New(t)
+
+	first := NewRestartableTask("first", task.Config{FallNumber: -1, RestartTimeout: time.Hour})
+	op := NewOperator(first).WithShutdownSignals(nil)
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	first.failCh <- errors.New("expected error")
+	waitFor(t, func() bool {
+		return op.GetTaskSnapshots()["first"].Status == task.StatusWaitingRestart
+	})
+
+	code, report := getHealthReport(t, op.HealthHandler(), "/readyz")
+	r.Equal(http.StatusServiceUnavailable, code)
+	r.Equal("waiting_restart", report.Tasks[0].State)
+	r.NotNil(report.Tasks[0].RestartAt)
+	r.WithinDuration(time.Now().Add(time.Hour), *report.Tasks[0].RestartAt, time.Second)
+	r.Equal("expected error", report.Tasks[0].LastError)
+
+	// shutdown doesn't wait delay of restart
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	r.NoError(op.Shutdown(ctx))
+	select {
+	case err := <-tCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("operator isn't stopped")
+	}
+	r.Equal(int64(1), first.getRunCount())
+}
@@ -1,18 +1,139 @@
 package gomultitask
 
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+// Log attributes used by operator
+const (
+	LogKeyTaskID     = "task_id"
+	LogKeyFallNumber = "fall_number"
+	LogKeyState      = "state"
+	LogKeySignal     = "signal"
+	LogKeyDuration   = "duration"
+	LogKeyError      = "error"
+	LogKeyStack      = "stack"
+	LogKeyStage      = "stage"
+	LogKeyDump       = "dump"
+)
+
+// Logger is simple printf logger, use WithSlog for structured logging
 type Logger interface {
 	Infof(string, ...interface{})
 	Errorf(string, ...interface{})
 }
 
-func (o *Operator) logInfof(msg string, args ...interface{}) {
+// loggerHandler is adapter of Logger to slog.Handler,
+// debug and info records are written by Infof, warn and error records are written by Errorf,
+// attributes are appended to message like key=value
+type loggerHandler struct {
+	log    Logger
+	attrs  string
+	prefix string
+}
+
+// NewLoggerHandler return slog.Handler which writes records to Logger, debug records are skipped
+func NewLoggerHandler(log Logger) slog.Handler {
+	return &loggerHandler{log: log}
+}
+
+// Enabled implements slog.Handler
+func (h *loggerHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo
+}
+
+// Handle implements slog.Handler
+func (h *loggerHandler) Handle(_ context.Context, record slog.Record) error {
+	var sb strings.Builder
+	sb.WriteString(record.Message)
+	sb.WriteString(h.attrs)
+	record.Attrs(func(attr slog.Attr) bool {
+		writeAttr(&sb, h.prefix, attr)
+		return true
+	})
+	if record.Level >= slog.LevelWarn {
+		h.log.Errorf("%s", sb.String())
+		return nil
+	}
+	h.log.Infof("%s", sb.String())
+	return nil
+}
+
+// WithAttrs implements slog.Handler
+func (h *loggerHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var sb strings.Builder
+	sb.WriteString(h.attrs)
+	for _, attr := range attrs {
+		writeAttr(&sb, h.prefix, attr)
+	}
+	return &loggerHandler{log: h.log, attrs: sb.String(), prefix: h.prefix}
+}
+
+// WithGroup implements slog.Handler
+func (h *loggerHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &loggerHandler{log: h.log, attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+func writeAttr(sb *strings.Builder, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix += attr.Key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			writeAttr(sb, groupPrefix, groupAttr)
+		}
+		return
+	}
+	sb.WriteString(" ")
+	sb.WriteString(prefix)
+	sb.WriteString(attr.Key)
+	sb.WriteString("=")
+	sb.WriteString(attr.Value.String())
+}
+
+func (o *Operator) logDebug(msg string, args ...interface{}) {
+	if o.log != nil {
+		o.log.Debug(msg, args...)
+	}
+}
+
+func (o *Operator) logInfo(msg string, args ...interface{}) {
+	if o.log != nil {
+		o.log.Info(msg, args...)
+	}
+}
+
+func (o *Operator) logWarn(msg string, args ...interface{}) {
 	if o.log != nil {
-		o.log.Infof(msg, args...)
+		o.log.Warn(msg, args...)
 	}
 }
 
-func (o *Operator) logErrorf(msg string, args ...interface{}) {
+func (o *Operator) logError(msg string, args ...interface{}) {
 	if o.log != nil {
-		o.log.Errorf(msg, args...)
+		o.log.Error(msg, args...)
+	}
+}
+
+// getErrorAttrs return attributes of error, stack trace is added for panic of task
+func getErrorAttrs(err error) []interface{} {
+	attrs := []interface{}{LogKeyError, err}
+	var panicErr *task.PanicError
+	if errors.As(err, &panicErr) {
+		attrs = append(attrs, LogKeyStack, string(panicErr.Stack))
 	}
+	return attrs
 }
@@ -0,0 +1,114 @@
+package gomultitask
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+func TestLoggerHandler(t *testing.T) {
+	r := require.New(t)
+
+	tLogger := NewTestingLogger()
+	log := slog.New(NewLoggerHandler(tLogger))
+	log.Debug("debug message")
+	log.Info("info message", LogKeyTaskID, "first", LogKeyFallNumber, 2)
+	log.Warn("warn message", LogKeyError, errors.New("expected error"))
+	log.With("operator", "root").WithGroup("task").Error("error message", "id", "first", slog.Group("cfg", "falls", 1))
+
+	r.Len(tLogger.infoChan, 1)
+	r.Equal("info message task_id=first fall_number=2", <-tLogger.infoChan)
+	r.Len(tLogger.errChan, 2)
+	r.Equal("warn message error=expected error", <-tLogger.errChan)
+	r.Equal("error message operator=root task.id=first task.cfg.falls=1", <-tLogger.errChan)
+}
+
+func TestLoggerHandler_Format(t *testing.T) {
+	r := require.New(t)
+
+	tLogger := NewTestingLogger()
+	slog.New(NewLoggerHandler(tLogger)).Info("100% done")
+	r.Equal("100% done", <-tLogger.infoChan)
+}
+
+func TestOperator_WithSlog(t *testing.T) {
+	r := require.New(t)
+
+	buf := &lockedBuffer{}
+	log := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	first := NewRestartableTask("first", task.Config{FallNumber: 1})
+	op := NewOperator(first).WithSlog(log)
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	first.failCh <- errors.New("expected error")
+	waitFor(t, func() bool {
+		return first.getRunCount() == 2
+	})
+	go func() {
+		op.sigCh <- syscall.SIGTERM
+	}()
+	select {
+	case err := <-tCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("Not shutdowned in expected time")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	records := make(map[string]map[string]interface{})
+	for _, line := range lines {
+		record := make(map[string]interface{})
+		r.NoError(json.Unmarshal([]byte(line), &record))
+		records[record["msg"].(string)] = record
+	}
+	r.Contains(records, "Task starting")
+	r.Equal("DEBUG", records["Task starting"]["level"])
+	r.Equal("first", records["Task starting"][LogKeyTaskID])
+	fell := records["Task fell, it will be restarted"]
+	r.Equal("WARN", fell["level"])
+	r.Equal("first", fell[LogKeyTaskID])
+	r.Equal(float64(1), fell[LogKeyFallNumber])
+	r.Equal("expected error", fell[LogKeyError])
+	r.Equal("terminated", records["Signal caught"][LogKeySignal])
+	r.Contains(records["Graceful shutdown finished"], LogKeyDuration)
+}
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestGetErrorAttrs(t *testing.T) {
+	r := require.New(t)
+
+	err := errors.New("expected error")
+	r.Equal([]interface{}{LogKeyError, err}, getErrorAttrs(err))
+
+	panicErr := &task.PanicError{Value: "expected panic", Stack: []byte("stack")}
+	r.Equal([]interface{}{LogKeyError, panicErr, LogKeyStack, "stack"}, getErrorAttrs(panicErr))
+}
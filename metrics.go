@@ -0,0 +1,122 @@
+package gomultitask
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// operatorMetrics are metrics of operator which aren't kept in state of tasks
+type operatorMetrics struct {
+	shutdownDuration      int64
+	shutdownDeadlineCount int64
+}
+
+// MetricsHandler return handler which exposes metrics of operator and tasks
+// in Prometheus text exposition format
+func (o *Operator) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", metricsContentType)
+		bw := bufio.NewWriter(w)
+		o.writeMetrics(bw)
+		_ = bw.Flush()
+	})
+}
+
+func (o *Operator) writeMetrics(w *bufio.Writer) {
+	tasks := o.getTaskList()
+	writeMetricHeader(w, "gomultitask_task_falls_total", "counter", "Number of falls of task.")
+	for _, t := range tasks {
+		writeTaskMetric(w, "gomultitask_task_falls_total", t, "", float64(t.GetState().GetFallNumber()))
+	}
+	writeMetricHeader(w, "gomultitask_task_restarts_total", "counter", "Number of restarts of task.")
+	for _, t := range tasks {
+		writeTaskMetric(w, "gomultitask_task_restarts_total", t, "", float64(t.GetState().GetRestartNumber()))
+	}
+	writeMetricHeader(w, "gomultitask_task_state", "gauge", "Current state of task.")
+	for _, t := range tasks {
+		current := t.GetState().GetStatus()
+		for _, status := range task.Statuses {
+			value := 0.0
+			if status == current {
+				value = 1
+			}
+			writeTaskMetric(w, "gomultitask_task_state", t, fmt.Sprintf(`,state="%s"`, status), value)
+		}
+	}
+	writeMetricHeader(w, "gomultitask_task_ready", "gauge", "Readiness of task.")
+	for _, t := range tasks {
+		value := 0.0
+		if t.IsReady() {
+			value = 1
+		}
+		writeTaskMetric(w, "gomultitask_task_ready", t, "", value)
+	}
+	writeMetricHeader(w, "gomultitask_task_uptime_seconds", "gauge", "Duration of current run of task.")
+	for _, t := range tasks {
+		writeTaskMetric(w, "gomultitask_task_uptime_seconds", t, "", t.GetState().GetUptime().Seconds())
+	}
+	writeMetricHeader(
+		w,
+		"gomultitask_task_last_error_timestamp_seconds",
+		"gauge",
+		"Unix time of last error returned by task, 0 if there wasn't errors.",
+	)
+	for _, t := range tasks {
+		value := 0.0
+		if lastErrorAt := t.GetState().GetLastErrorAt(); !lastErrorAt.IsZero() {
+			value = float64(lastErrorAt.UnixNano()) / float64(time.Second)
+		}
+		writeTaskMetric(w, "gomultitask_task_last_error_timestamp_seconds", t, "", value)
+	}
+
+	writeMetricHeader(w, "gomultitask_shutdown_duration_seconds", "gauge", "Duration of last graceful shutdown.")
+	fmt.Fprintf(
+		w,
+		"gomultitask_shutdown_duration_seconds %s\n",
+		formatMetricValue(time.Duration(atomic.LoadInt64(&o.metrics.shutdownDuration)).Seconds()),
+	)
+	writeMetricHeader(
+		w,
+		"gomultitask_shutdown_deadline_exceeded_total",
+		"counter",
+		"Number of graceful shutdowns which reached deadline.",
+	)
+	fmt.Fprintf(
+		w,
+		"gomultitask_shutdown_deadline_exceeded_total %d\n",
+		atomic.LoadInt64(&o.metrics.shutdownDeadlineCount),
+	)
+	writeMetricHeader(
+		w,
+		"gomultitask_events_dropped_total",
+		"counter",
+		"Number of events which weren't delivered to subscribers with full buffer.",
+	)
+	fmt.Fprintf(w, "gomultitask_events_dropped_total %d\n", o.GetDroppedEventsNumber())
+}
+
+func writeMetricHeader(w *bufio.Writer, name, metricType, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func writeTaskMetric(w *bufio.Writer, name string, t *task.Task, labels string, value float64) {
+	fmt.Fprintf(w, "%s{task=\"%s\"%s} %s\n", name, escapeLabelValue(t.GetID()), labels, formatMetricValue(value))
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
+
+func formatMetricValue(value float64) string {
+	return fmt.Sprintf("%g", value)
+}
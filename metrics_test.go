@@ -0,0 +1,73 @@
+package gomultitask
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+func TestEscapeLabelValue(t *testing.T) {
+	require.Equal(t, `a\\b\"c\nd`, escapeLabelValue("a\\b\"c\nd"))
+}
+
+func TestOperator_MetricsHandler(t *testing.T) {
+	r := require.New(t)
+
+	first := NewRestartableTask("first", task.Config{FallNumber: 1})
+	second := NewRestartableTask("second", task.Config{})
+	op := NewOperator(first, second)
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	first.failCh <- errors.New("expected error")
+	waitFor(t, func() bool {
+		return first.getRunCount() == 2 && second.getRunCount() == 1
+	})
+
+	rec := httptest.NewRecorder()
+	op.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	r.Equal(metricsContentType, rec.Header().Get("Content-Type"))
+	body := rec.Body.String()
+	r.Contains(body, "# TYPE gomultitask_task_falls_total counter\n")
+	r.Contains(body, "gomultitask_task_falls_total{task=\"first\"} 1\n")
+	r.Contains(body, "gomultitask_task_falls_total{task=\"second\"} 0\n")
+	r.Contains(body, "gomultitask_task_restarts_total{task=\"first\"} 1\n")
+	r.Contains(body, "gomultitask_task_state{task=\"first\",state=\"running\"} 1\n")
+	r.Contains(body, "gomultitask_task_state{task=\"first\",state=\"failed\"} 0\n")
+	r.Contains(body, "gomultitask_task_ready{task=\"second\"} 1\n")
+	r.Contains(body, "gomultitask_task_uptime_seconds{task=\"first\"} ")
+	r.NotContains(body, "gomultitask_task_last_error_timestamp_seconds{task=\"first\"} 0\n")
+	r.Contains(body, "gomultitask_task_last_error_timestamp_seconds{task=\"second\"} 0\n")
+	r.Contains(body, "gomultitask_shutdown_duration_seconds 0\n")
+	r.Contains(body, "gomultitask_shutdown_deadline_exceeded_total 0\n")
+	r.Contains(body, "gomultitask_events_dropped_total 0\n")
+
+	go func() {
+		op.sigCh <- syscall.SIGTERM
+	}()
+	select {
+	case err := <-tCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("Not shutdowned in expected time")
+	}
+	// run of task is finished a bit later than its shutdown
+	waitFor(t, func() bool {
+		return !op.tasks[1].GetState().IsRunning()
+	})
+	rec = httptest.NewRecorder()
+	op.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body2, err := ioutil.ReadAll(rec.Body)
+	r.NoError(err)
+	r.Contains(string(body2), "gomultitask_task_state{task=\"second\",state=\"stopped\"} 1\n")
+	r.NotContains(string(body2), "gomultitask_shutdown_duration_seconds 0\n")
+}
@@ -2,6 +2,9 @@ package gomultitask
 
 import (
 	"context"
+	"errors"
+	"io"
+	"log/slog"
 	"os"
 	"os/signal"
 	"sync"
@@ -10,128 +13,488 @@ import (
 	"time"
 
 	"github.com/andrskom/gomultitask/task"
+	"github.com/andrskom/gomultitask/trace"
 )
 
 const defaultShutdownDeadline = 30 * time.Second
 
-// Operator is main struct for managing tasks
+var _ task.Interface = (*Operator)(nil)
+
+// Operator is main struct for managing tasks,
+// it implements task.Interface, so it can be used like task of another operator
 type Operator struct {
-	log              Logger
-	tasks            []*task.Task
+	id               string
+	taskCfg          task.Config
+	log              *slog.Logger
 	notHandledErr    chan task.Err
 	sigCh            chan os.Signal
-	errCh            chan error
-	quitCh           chan struct{}
+	errCh            chan task.Err
+	completedCh      chan *task.Task
+	shutdownCh       chan context.Context
+	quitCh           chan error
 	shutdownSignals  []os.Signal
 	shutdownDeadline time.Duration
+	// actions on repeated shutdown signals
+	escalationStages   []EscalationStage
+	escalationDeadline time.Duration
+	exit               func(code int)
+	// reload of tasks by signals
+	reloadCh              chan os.Signal
+	reloadSignals         []os.Signal
+	reloadTimeout         time.Duration
+	reloadRestartFallback bool
+	// diagnostic dump by signals
+	diagnosticCh      chan os.Signal
+	diagnosticSignals []os.Signal
+	diagnosticWriter  io.Writer
+	strategy          Strategy
+	metrics           operatorMetrics
+	events            *eventBus
+	tracer            trace.Tracer
+
+	// tasks are guarded by tasksMu, because they can be added and removed while operator is running
+	tasksMu  sync.RWMutex
+	tasks    []*task.Task
+	runCtx   context.Context
+	stopping bool
+	taskRuns map[*task.Task]*taskRun
+
+	replicasMu sync.Mutex
+	replicas   map[string]*replicaSet
+
+	runMu   sync.Mutex
+	runDone chan struct{}
 }
 
-// NewOperator init default operator for tasks
+// NewOperator init default operator for tasks,
+// tasks are sorted by dependencies, it panics if dependencies are invalid or have cycle
 func NewOperator(list ...task.Interface) *Operator {
 	taskList := make([]*task.Task, 0)
 	notHandledErr := make(chan task.Err, 5)
 	for _, t := range list {
 		taskList = append(taskList, task.NewFromInterface(notHandledErr, t))
 	}
+	taskList, err := sortByDependencies(taskList)
+	if err != nil {
+		panic(err)
+	}
 
-	return &Operator{
-		tasks:            taskList,
-		notHandledErr:    notHandledErr,
-		sigCh:            make(chan os.Signal, 1),
-		errCh:            make(chan error),
-		quitCh:           make(chan struct{}),
-		shutdownSignals:  []os.Signal{syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT},
-		shutdownDeadline: defaultShutdownDeadline,
+	o := &Operator{
+		taskCfg:            task.GetDefaultConfig(),
+		tasks:              taskList,
+		notHandledErr:      notHandledErr,
+		sigCh:              make(chan os.Signal, 1),
+		errCh:              make(chan task.Err),
+		completedCh:        make(chan *task.Task),
+		shutdownCh:         make(chan context.Context, 1),
+		quitCh:             make(chan error),
+		shutdownSignals:    []os.Signal{syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT},
+		shutdownDeadline:   defaultShutdownDeadline,
+		escalationStages:   []EscalationStage{EscalationForceCancel, EscalationExit},
+		escalationDeadline: defaultEscalationDeadline,
+		exit:               os.Exit,
+		reloadCh:           make(chan os.Signal, 1),
+		diagnosticCh:       make(chan os.Signal, 1),
+		reloadTimeout:      defaultReloadTimeout,
+		strategy:           StrategyOneForOne,
+		events:             newEventBus(),
+		replicas:           make(map[string]*replicaSet),
+	}
+	for _, t := range taskList {
+		t.SetListener(o.handleTaskEvent)
 	}
+	return o
+}
+
+// NewChildOperator init operator for using like task of another operator,
+// it doesn't catch signals, parent operator is responsible for them
+func NewChildOperator(id string, list ...task.Interface) *Operator {
+	return NewOperator(list...).WithID(id).WithShutdownSignals(nil).WithReloadSignals(nil).WithDiagnosticSignals(nil)
+}
+
+// WithID set id of operator, it's used when operator is task of another operator
+func (o *Operator) WithID(id string) *Operator {
+	o.id = id
+	return o
 }
 
-// WithLogger add logger
+// WithTaskConfig set config which is used when operator is task of another operator
+func (o *Operator) WithTaskConfig(cfg task.Config) *Operator {
+	o.taskCfg = cfg
+	return o
+}
+
+// WithLogger add printf logger, it's adapted to structured logging by NewLoggerHandler
 func (o *Operator) WithLogger(log Logger) *Operator {
+	o.log = slog.New(NewLoggerHandler(log))
+	return o
+}
+
+// WithSlog add structured logger
+func (o *Operator) WithSlog(log *slog.Logger) *Operator {
 	o.log = log
 	return o
 }
 
-// WithShutdownDeadline add shutdown deadline, default is 30s
+// WithShutdownDeadline add shutdown deadline, default is 30s,
+// context with this deadline is passed to Shutdown of tasks,
+// context of tasks which are still running after deadline is canceled
 func (o *Operator) WithShutdownDeadline(duration time.Duration) *Operator {
 	o.shutdownDeadline = duration
 	return o
 }
 
-// WithShutdownSignals set custom shutdown signals
+// WithShutdownSignals set custom shutdown signals, empty list disables catching of signals
 func (o *Operator) WithShutdownSignals(signals []os.Signal) *Operator {
 	o.shutdownSignals = signals
 	return o
 }
 
-// Run tasks and wait while stop
+// WithTracer set tracer for spans of run attempts, restart waits and shutdown of tasks,
+// default tracer doesn't record anything
+func (o *Operator) WithTracer(tracer trace.Tracer) *Operator {
+	o.tracer = tracer
+	for _, t := range o.getTaskList() {
+		t.SetTracer(tracer)
+	}
+	return o
+}
+
+// WithStrategy set supervising strategy, default is StrategyOneForOne
+func (o *Operator) WithStrategy(strategy Strategy) *Operator {
+	o.strategy = strategy
+	return o
+}
+
+// Run tasks and wait while stop, done of ctx starts graceful shutdown,
+// return *StopError if task failed, graceful shutdown had problems or ctx was done,
+// return nil if operator was stopped by signal or Shutdown and all tasks were stopped without errors
 func (o *Operator) Run(ctx context.Context) error {
+	done := make(chan struct{})
+	o.runMu.Lock()
+	o.runDone = done
+	o.runMu.Unlock()
+	defer func() {
+		o.runMu.Lock()
+		o.runDone = nil
+		o.runMu.Unlock()
+		close(done)
+	}()
+
 	// signals catcher
-	signal.Notify(o.sigCh, o.shutdownSignals...)
+	if shutdownSignals := o.getShutdownSignals(); len(shutdownSignals) > 0 {
+		signal.Notify(o.sigCh, shutdownSignals...)
+		defer signal.Stop(o.sigCh)
+	}
 
-	// internal context for supply routines
-	internalCtx, cancelF := context.WithCancel(ctx)
-	defer cancelF()
+	// internal context for supply routines and tasks, it isn't canceled with ctx,
+	// done of ctx starts graceful shutdown like signal
+	internalCtx, cancelF := context.WithCancelCause(context.WithoutCancel(ctx))
+	defer cancelF(ErrOperatorStopped)
 	// init background notHandledErr logger
 	go o.logNotHandledErr(internalCtx)
 
-	// run all tasks
+	// reload signals catcher
+	if len(o.reloadSignals) > 0 {
+		signal.Notify(o.reloadCh, o.reloadSignals...)
+		defer signal.Stop(o.reloadCh)
+		go o.handleReloadSignals(internalCtx)
+	}
+
+	// diagnostic signals catcher
+	if len(o.diagnosticSignals) > 0 {
+		signal.Notify(o.diagnosticCh, o.diagnosticSignals...)
+		defer signal.Stop(o.diagnosticCh)
+		go o.handleDiagnosticSignals(internalCtx)
+	}
+
+	// run all tasks, state is reset, because operator can be restarted by parent operator
+	o.tasksMu.Lock()
+	o.runCtx = internalCtx
+	o.stopping = false
+	o.taskRuns = make(map[*task.Task]*taskRun, len(o.tasks))
 	for _, t := range o.tasks {
-		go func(t *task.Task) {
-			if err := t.Run(ctx); err != nil {
-				o.errCh <- err
-			}
-		}(t)
+		t.ResetState()
+	}
+	for _, t := range o.tasks {
+		o.startTask(internalCtx, t)
 	}
+	o.tasksMu.Unlock()
+	defer func() {
+		o.tasksMu.Lock()
+		o.runCtx = nil
+		o.tasksMu.Unlock()
+	}()
+
+	// notify parent operator about readiness
+	go o.notifyReady(internalCtx)
 
 	// wait signal or error group
-	go o.waitEnd(context.Background())
+	go o.waitEnd(ctx, cancelF)
 
 	// wait end of graceful shutdown
-	<-o.quitCh
+	return <-o.quitCh
+}
+
+// Shutdown request graceful shutdown of operator and wait while it will be finished,
+// deadline of ctx limits shutdown of tasks too
+func (o *Operator) Shutdown(ctx context.Context) error {
+	select {
+	case o.shutdownCh <- ctx:
+	default:
+	}
+	o.runMu.Lock()
+	done := o.runDone
+	o.runMu.Unlock()
+	if done == nil {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GetTaskConfig return config of operator like task
+func (o *Operator) GetTaskConfig() task.Config {
+	return o.taskCfg
+}
+
+// GetID return id of operator like task
+func (o *Operator) GetID() string {
+	return o.id
+}
+
+// IsReady return true if all tasks are ready now, completed tasks aren't taken into account
+func (o *Operator) IsReady() bool {
+	for _, t := range o.getTaskList() {
+		if !t.IsReady() && !t.IsCompleted() {
+			return false
+		}
+	}
+	return true
+}
+
+// GetTaskSnapshots return snapshots of state of tasks by their ids
+func (o *Operator) GetTaskSnapshots() map[string]task.Snapshot {
+	tasks := o.getTaskList()
+	res := make(map[string]task.Snapshot, len(tasks))
+	for _, t := range tasks {
+		res[t.GetID()] = t.GetState().Snapshot()
+	}
+	return res
+}
+
+// notifyReady notify parent operator when all tasks are ready first time
+func (o *Operator) notifyReady(ctx context.Context) {
+	for _, t := range o.getTaskList() {
+		select {
+		case <-t.Ready():
+		case <-ctx.Done():
+			return
+		}
+	}
+	task.NotifyReady(ctx)
+}
 
-	return nil
+// waitEnd wait reason of stop and start graceful shutdown, shutdown isn't limited by done of ctx
+func (o *Operator) waitEnd(ctx context.Context, cancelTasks context.CancelCauseFunc) {
+	shutdownCtx := context.WithoutCancel(ctx)
+	for {
+		if o.waitEndStep(ctx, shutdownCtx, cancelTasks) {
+			return
+		}
+	}
 }
 
-func (o *Operator) waitEnd(ctx context.Context) {
+// waitEndStep handle one event of operator, it returns true if shutdown is done
+func (o *Operator) waitEndStep(ctx, shutdownCtx context.Context, cancelTasks context.CancelCauseFunc) bool {
 	select {
 	case sig := <-o.sigCh:
-		o.logInfof("Signal caught: %s", sig.String())
-		o.shutdown(ctx)
+		o.logInfo("Signal caught", LogKeySignal, sig.String())
+		o.events.publish(Event{Type: EventSignalReceived, Signal: sig})
+		o.shutdown(shutdownCtx, cancelTasks, &StopError{Reason: StopReasonSignal, Signal: sig})
+	case requestCtx := <-o.shutdownCh:
+		o.logInfo("Shutdown requested")
+		o.shutdown(requestCtx, cancelTasks, &StopError{Reason: StopReasonShutdown})
+	case <-ctx.Done():
+		cause := context.Cause(ctx)
+		o.logInfo("Context of operator is done", LogKeyError, cause)
+		o.shutdown(shutdownCtx, cancelTasks, &StopError{Reason: StopReasonContextDone, Cause: cause})
 	case err := <-o.errCh:
-		o.logErrorf("Error in group caught: %s", err.Error())
-		o.shutdown(ctx)
+		o.logError(
+			"Task failed, operator is stopping",
+			append([]interface{}{LogKeyTaskID, err.ID, LogKeyFallNumber, err.FallNumber}, getErrorAttrs(err.Err)...)...,
+		)
+		o.shutdown(shutdownCtx, cancelTasks, &StopError{Reason: StopReasonTaskFailed, TaskID: err.ID, TaskErr: err.Err})
+	case t := <-o.completedCh:
+		o.logInfo("Task completed", LogKeyTaskID, t.GetID())
+		switch {
+		case t.GetCompletionPolicy() == task.CompletionStop:
+			o.shutdown(shutdownCtx, cancelTasks, &StopError{Reason: StopReasonCompleted, TaskID: t.GetID()})
+		case o.allTasksCompleted():
+			o.logInfo("All tasks completed")
+			o.shutdown(shutdownCtx, cancelTasks, &StopError{Reason: StopReasonCompleted})
+		default:
+			return false
+		}
 	}
+	return true
 }
 
-func (o *Operator) shutdown(ctx context.Context) {
+// shutdown stop tasks in reverse order of dependencies,
+// tasks which aren't stopped before deadline are stopped by cancel of their context,
+// shutdown signals caught while shutdown escalate it
+func (o *Operator) shutdown(ctx context.Context, cancelTasks context.CancelCauseFunc, stopErr *StopError) {
+	ctx, cancel := context.WithTimeoutCause(ctx, o.shutdownDeadline, ErrShutdownDeadline)
+	defer cancel()
+	ctx, cancelShutdown := context.WithCancelCause(ctx)
+	defer cancelShutdown(nil)
+	esc := &escalation{cancel: cancelShutdown}
+	if stopErr.Reason != StopReasonSignal {
+		esc.stage = -1
+	}
+	defer esc.stop()
+	startedAt := time.Now()
+	// tasks can't be added or removed after start of shutdown
+	o.tasksMu.Lock()
+	o.stopping = true
+	tasks := append([]*task.Task(nil), o.tasks...)
+	o.tasksMu.Unlock()
+	o.events.publish(Event{
+		Type:   EventShutdownStarted,
+		TaskID: stopErr.TaskID,
+		Err:    stopErr.TaskErr,
+		Signal: stopErr.Signal,
+	})
 	var wg sync.WaitGroup
-	var shutdownErrCount int64
-	for _, t := range o.tasks {
+	// errors of tasks stopped after deadline aren't collected
+	var errMu sync.Mutex
+	var errCollected bool
+	var shutdownErrs []*ShutdownError
+	// task is stopped after all tasks which depend on it
+	stopped := make(map[*task.Task]chan struct{}, len(tasks))
+	for _, t := range tasks {
+		stopped[t] = make(chan struct{})
+	}
+	for _, t := range tasks {
 		wg.Add(1)
 		go func(t *task.Task) {
 			defer wg.Done()
+			defer close(stopped[t])
+			for _, dependent := range o.getDependents(t) {
+				<-stopped[dependent]
+			}
 			if err := t.Shutdown(ctx); err != nil {
-				atomic.AddInt64(&shutdownErrCount, 1)
-				o.logErrorf("Shutdown task ID %s, err %s", t.GetID(), err.Error())
+				o.logError("Shutdown of task failed", LogKeyTaskID, t.GetID(), LogKeyError, err)
+				errMu.Lock()
+				if !errCollected {
+					shutdownErrs = append(shutdownErrs, &ShutdownError{TaskID: t.GetID(), Err: err})
+				}
+				errMu.Unlock()
 			}
 		}(t)
 	}
 	shutdownFinishedCH := make(chan struct{})
 	go func() {
 		wg.Wait()
-		shutdownFinishedCH <- struct{}{}
+		close(shutdownFinishedCH)
 	}()
-	select {
-	case <-shutdownFinishedCH:
-		if shutdownErrCount > 0 {
-			o.logErrorf("Have got %d errors while shutdown tasks", shutdownErrCount)
-			break
+	for finished := false; !finished; {
+		select {
+		case sig := <-o.sigCh:
+			o.escalate(sig, esc)
+		case <-shutdownFinishedCH:
+			if len(shutdownErrs) > 0 {
+				o.logError(
+					"Graceful shutdown finished with errors",
+					"errors", len(shutdownErrs),
+					LogKeyDuration, time.Since(startedAt),
+				)
+			} else {
+				o.logInfo("Graceful shutdown finished", LogKeyDuration, time.Since(startedAt))
+			}
+			finished = true
+		case <-ctx.Done():
+			o.stopUnfinished(ctx, cancelTasks, stopErr, startedAt)
+			finished = true
+		}
+	}
+	if stopErr.DeadlineExceeded || stopErr.Forced {
+		for _, t := range tasks {
+			select {
+			case <-stopped[t]:
+			default:
+				stopErr.UnfinishedTasks = append(stopErr.UnfinishedTasks, t.GetID())
+			}
+		}
+		o.waitCanceledRuns(tasks, esc)
+	}
+	atomic.StoreInt64(&o.metrics.shutdownDuration, int64(time.Since(startedAt)))
+
+	errMu.Lock()
+	errCollected = true
+	stopErr.ShutdownErrs = shutdownErrs
+	errMu.Unlock()
+
+	if stopErr.isClean() {
+		o.events.publish(Event{Type: EventShutdownCompleted})
+		o.quitCh <- nil
+		return
+	}
+	o.events.publish(Event{Type: EventShutdownCompleted, Err: stopErr})
+	o.quitCh <- stopErr
+}
+
+// waitCanceledRuns wait return of Run of tasks after cancel of their context while shutdown is escalated,
+// so repeated signal can run the next stage if task ignores context
+func (o *Operator) waitCanceledRuns(tasks []*task.Task, esc *escalation) {
+	if esc.stage <= 0 {
+		return
+	}
+	o.tasksMu.RLock()
+	runs := make([]*taskRun, 0, len(tasks))
+	for _, t := range tasks {
+		if run := o.taskRuns[t]; run != nil {
+			runs = append(runs, run)
+		}
+	}
+	o.tasksMu.RUnlock()
+	for _, run := range runs {
+		for done := false; !done; {
+			if esc.stage >= len(o.escalationStages) {
+				return
+			}
+			select {
+			case <-run.done:
+				done = true
+			case sig := <-o.sigCh:
+				o.escalate(sig, esc)
+			}
 		}
-		o.logInfof("All graceful shutdowned")
-	case <-time.After(o.shutdownDeadline):
-		o.logErrorf("Deadline for graceful shutdown is reached")
 	}
-	o.quitCh <- struct{}{}
+}
+
+// stopUnfinished cancel context of tasks when deadline is reached or shutdown is forced
+func (o *Operator) stopUnfinished(
+	ctx context.Context,
+	cancelTasks context.CancelCauseFunc,
+	stopErr *StopError,
+	startedAt time.Time,
+) {
+	cause := context.Cause(ctx)
+	if errors.Is(cause, ErrForcedShutdown) {
+		o.logError("Graceful shutdown is forced", LogKeyDuration, time.Since(startedAt))
+		stopErr.Forced = true
+	} else {
+		o.logError("Deadline for graceful shutdown is reached", LogKeyDuration, time.Since(startedAt))
+		atomic.AddInt64(&o.metrics.shutdownDeadlineCount, 1)
+		stopErr.DeadlineExceeded = true
+		cause = ErrShutdownDeadline
+	}
+	cancelTasks(cause)
 }
 
 func (o *Operator) logNotHandledErr(ctx context.Context) {
@@ -140,7 +503,11 @@ func (o *Operator) logNotHandledErr(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case err := <-o.notHandledErr:
-			o.logErrorf("ID: %s, FallNumber: %d, Err: %#v", err.ID, err.FallNumber, err.Err)
+			o.logWarn(
+				"Task fell, it will be restarted",
+				append([]interface{}{LogKeyTaskID, err.ID, LogKeyFallNumber, err.FallNumber}, getErrorAttrs(err.Err)...)...,
+			)
+			o.restartSiblings(ctx, err.ID)
 		}
 	}
 }
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 	"syscall"
 	"testing"
 	"time"
@@ -13,6 +16,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/andrskom/gomultitask/task"
+	"github.com/andrskom/gomultitask/trace"
 )
 
 type TestingTask struct {
@@ -100,7 +104,11 @@ func TestBaseConfiguration(t *testing.T) {
 	eLogger := NewTestingLogger()
 	op.WithLogger(eLogger)
 	r.NotNil(op.log)
-	r.Equal(eLogger, op.log)
+	r.Equal(NewLoggerHandler(eLogger), op.log.Handler())
+
+	eSlog := slog.New(slog.NewTextHandler(io.Discard, nil))
+	op.WithSlog(eSlog)
+	r.Equal(eSlog, op.log)
 
 	eDeadline := 5 * time.Second
 	op.WithShutdownDeadline(eDeadline)
@@ -126,7 +134,7 @@ func TestErrLogging(t *testing.T) {
 	for i := 1; i <= 3; i++ {
 		errMsgList = append(
 			errMsgList,
-			fmt.Sprintf("ID: testingTask%d, FallNumber: 1, Err: &errors.errorString{s:\"expected err %d\"}", i, i),
+			fmt.Sprintf("Task fell, it will be restarted task_id=testingTask%d fall_number=1 error=expected err %d", i, i),
 		)
 		tasks[i].finishTaskCh <- fmt.Errorf("expected err %d", i)
 	}
@@ -200,10 +208,16 @@ func TestCorrectShutdownByGroupStop(t *testing.T) {
 	go func() {
 		tCh <- op.Run(context.Background())
 	}()
-	tasks[0].finishTaskCh <- errors.New("expected error")
+	eErr := errors.New("expected error")
+	tasks[0].finishTaskCh <- eErr
 	select {
 	case err := <-tCh:
-		r.NoError(err)
+		r.True(errors.Is(err, eErr))
+		var stopErr *StopError
+		r.True(errors.As(err, &stopErr))
+		r.Equal(StopReasonTaskFailed, stopErr.Reason)
+		r.Equal("testingTask0", stopErr.TaskID)
+		r.Equal(ExitCodeTaskFailed, ExitCode(err))
 	case <-time.After(1 * time.Second):
 		r.Fail("Not shutdowned in expected time")
 	}
@@ -221,7 +235,8 @@ func TestCorrectShutdownByPanic(t *testing.T) {
 	tasks[0].panicTask <- "expected panic"
 	select {
 	case err := <-tCh:
-		r.NoError(err)
+		r.Error(err)
+		r.Contains(err.Error(), "expected panic")
 	case <-time.After(1 * time.Second):
 		r.Fail("Not shutdowned in expected time")
 	}
@@ -245,7 +260,9 @@ func TestShutdownErr(t *testing.T) {
 	}()
 	select {
 	case err := <-tCh:
-		r.NoError(err)
+		r.True(errors.Is(err, eErr))
+		r.EqualError(err, "operator stopped by signal terminated; shutdown task ID testingTask3: expected error")
+		r.Equal(ExitCodeShutdownFailed, ExitCode(err))
 	case <-time.After(1 * time.Second):
 		r.Fail("Not shutdowned in expected time")
 	}
@@ -254,7 +271,7 @@ TestWaiter:
 	for {
 		select {
 		case msg := <-tLogger.errChan:
-			if msg == "Have got 1 errors while shutdown tasks" {
+			if strings.HasPrefix(msg, "Graceful shutdown finished with errors errors=1 duration=") {
 				foundShutdownErr = true
 				break TestWaiter
 			}
@@ -284,7 +301,11 @@ func TestShutdownDeadline(t *testing.T) {
 	}()
 	select {
 	case err := <-tCh:
-		r.NoError(err)
+		r.True(errors.Is(err, ErrShutdownDeadline))
+		var stopErr *StopError
+		r.True(errors.As(err, &stopErr))
+		r.Equal([]string{"testingTask3"}, stopErr.UnfinishedTasks)
+		r.Equal(ExitCodeShutdownDeadline, ExitCode(err))
 	case <-time.After(2 * time.Second):
 		r.Fail("Not shutdowned in expected time")
 	}
@@ -293,7 +314,7 @@ TestWaiter:
 	for {
 		select {
 		case msg := <-tLogger.errChan:
-			if msg == "Deadline for graceful shutdown is reached" {
+			if strings.HasPrefix(msg, "Deadline for graceful shutdown is reached duration=") {
 				foundShutdownErr = true
 				break TestWaiter
 			}
@@ -303,3 +324,425 @@ TestWaiter:
 	}
 	r.True(foundShutdownErr)
 }
+
+func TestChildOperator(t *testing.T) {
+	r := require.New(t)
+
+	tasks := getPreparedTask(t, 2)
+	child := NewChildOperator("child", tasks[0], tasks[1])
+	r.Equal("child", child.GetID())
+	r.Empty(child.shutdownSignals)
+	r.Equal(task.GetDefaultConfig(), child.GetTaskConfig())
+
+	eCfg := task.Config{FallNumber: 3}
+	child.WithTaskConfig(eCfg)
+	r.Equal(eCfg, child.GetTaskConfig())
+}
+
+func TestChildOperator_Shutdown(t *testing.T) {
+	r := require.New(t)
+
+	tasks := getPreparedTask(t, 2)
+	child := NewChildOperator("child", tasks[0], tasks[1])
+	r.NoError(child.Shutdown(context.Background()))
+	tCh := make(chan error)
+	go func() {
+		tCh <- child.Run(context.Background())
+	}()
+	select {
+	case err := <-tCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("Not shutdowned in expected time")
+	}
+}
+
+func TestChildOperator_FallPropagation(t *testing.T) {
+	r := require.New(t)
+
+	childTasks := getPreparedTask(t, 2)
+	child := NewChildOperator("child", childTasks[0], childTasks[1])
+	tasks := getPreparedTask(t, 1)
+	op := NewOperator(tasks[0], child)
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	eErr := errors.New("expected error")
+	childTasks[0].finishTaskCh <- eErr
+	select {
+	case err := <-tCh:
+		r.True(errors.Is(err, eErr))
+	case <-time.After(time.Second):
+		r.Fail("Not shutdowned in expected time")
+	}
+}
+
+func TestChildOperator_RestartByParent(t *testing.T) {
+	r := require.New(t)
+
+	childTasks := getPreparedTask(t, 2)
+	child := NewChildOperator("child", childTasks[0], childTasks[1]).WithTaskConfig(task.Config{FallNumber: 1})
+	tasks := getPreparedTask(t, 1)
+	tLogger := NewTestingLogger()
+	op := NewOperator(tasks[0], child).WithLogger(tLogger)
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	childTasks[0].finishTaskCh <- errors.New("expected error")
+	select {
+	case msg := <-tLogger.errChan:
+		r.Equal(
+			"Task fell, it will be restarted task_id=child fall_number=1 "+
+				"error=operator stopped by failed task ID testingTask0: expected error",
+			msg,
+		)
+	case <-time.After(time.Second):
+		r.Fail("Not get errs in time")
+	}
+	// child is run again with new state, so its task can fall once more
+	childTasks[0].finishTaskCh <- errors.New("expected error")
+	select {
+	case err := <-tCh:
+		r.Error(err)
+	case <-time.After(time.Second):
+		r.Fail("Not shutdowned in expected time")
+	}
+}
+
+type ReadyTask struct {
+	*RestartableTask
+	readyCh chan struct{}
+}
+
+func NewReadyTask(id string, cfg task.Config) *ReadyTask {
+	cfg.NotifyReady = true
+	return &ReadyTask{
+		RestartableTask: NewRestartableTask(id, cfg),
+		readyCh:         make(chan struct{}),
+	}
+}
+
+func (t *ReadyTask) Run(ctx context.Context) error {
+	go func() {
+		select {
+		case <-t.readyCh:
+			task.NotifyReady(ctx)
+		case <-ctx.Done():
+		}
+	}()
+	return t.RestartableTask.Run(ctx)
+}
+
+func TestOperator_IsReady(t *testing.T) {
+	r := require.New(t)
+
+	db := NewReadyTask("db", task.Config{})
+	api := NewReadyTask("api", task.Config{DependsOn: []string{"db"}})
+	op := NewOperator(api, db)
+	tCh := make(chan error)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		tCh <- op.Run(ctx)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	r.False(op.IsReady())
+	r.Equal(int64(0), api.getRunCount())
+
+	close(db.readyCh)
+	waitFor(t, func() bool {
+		return api.getRunCount() == 1
+	})
+	r.False(op.IsReady())
+
+	close(api.readyCh)
+	waitFor(t, op.IsReady)
+
+	go func() {
+		op.sigCh <- syscall.SIGTERM
+	}()
+	select {
+	case err := <-tCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("Not shutdowned in expected time")
+	}
+}
+
+func TestOperator_ReadyTimeout(t *testing.T) {
+	r := require.New(t)
+
+	db := NewReadyTask("db", task.Config{ReadyTimeout: 10 * time.Millisecond})
+	op := NewOperator(db)
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	select {
+	case err := <-tCh:
+		r.True(errors.Is(err, task.ErrNotReadyInTime))
+	case <-time.After(time.Second):
+		r.Fail("Not shutdowned in expected time")
+	}
+}
+
+func TestChildOperator_NotifyReady(t *testing.T) {
+	r := require.New(t)
+
+	childTask := NewReadyTask("childTask", task.Config{})
+	child := NewChildOperator("child", childTask).WithTaskConfig(task.Config{NotifyReady: true})
+	op := NewOperator(child)
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+	r.False(op.IsReady())
+	close(childTask.readyCh)
+	waitFor(t, op.IsReady)
+
+	go func() {
+		op.sigCh <- syscall.SIGTERM
+	}()
+	select {
+	case err := <-tCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("Not shutdowned in expected time")
+	}
+}
+
+type ContextTask struct {
+	id          string
+	cfg         task.Config
+	runCanceled chan struct{}
+	runCause    error
+	deadlineCh  chan time.Time
+}
+
+func NewContextTask(id string, cfg task.Config) *ContextTask {
+	return &ContextTask{
+		id:          id,
+		cfg:         cfg,
+		runCanceled: make(chan struct{}),
+		deadlineCh:  make(chan time.Time, 1),
+	}
+}
+
+func (t *ContextTask) Run(ctx context.Context) error {
+	<-ctx.Done()
+	t.runCause = context.Cause(ctx)
+	close(t.runCanceled)
+	return nil
+}
+
+func (t *ContextTask) Shutdown(ctx context.Context) error {
+	deadline, _ := ctx.Deadline()
+	t.deadlineCh <- deadline
+	<-ctx.Done()
+	if t.cfg.ShutdownTimeout <= 0 {
+		// task ignores deadline of operator
+		time.Sleep(50 * time.Millisecond)
+	}
+	return ctx.Err()
+}
+
+func (t *ContextTask) GetTaskConfig() task.Config {
+	return t.cfg
+}
+
+func (t *ContextTask) GetID() string {
+	return t.id
+}
+
+func TestShutdownDeadlineContext(t *testing.T) {
+	r := require.New(t)
+
+	first := NewContextTask("first", task.Config{})
+	second := NewContextTask("second", task.Config{ShutdownTimeout: 10 * time.Millisecond})
+	op := NewOperator(first, second).WithShutdownDeadline(100 * time.Millisecond)
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+	shutdownStarted := time.Now()
+	go func() {
+		op.sigCh <- syscall.SIGTERM
+	}()
+	select {
+	case err := <-tCh:
+		var stopErr *StopError
+		r.True(errors.As(err, &stopErr))
+		r.True(stopErr.DeadlineExceeded)
+		r.Len(stopErr.ShutdownErrs, 1)
+		r.Equal("second", stopErr.ShutdownErrs[0].TaskID)
+		r.True(errors.Is(stopErr.ShutdownErrs[0], context.DeadlineExceeded))
+		r.Equal([]string{"first"}, stopErr.UnfinishedTasks)
+	case <-time.After(time.Second):
+		r.Fail("Not shutdowned in expected time")
+	}
+	r.WithinDuration(shutdownStarted.Add(100*time.Millisecond), <-first.deadlineCh, 50*time.Millisecond)
+	r.WithinDuration(shutdownStarted.Add(10*time.Millisecond), <-second.deadlineCh, 50*time.Millisecond)
+	select {
+	case <-first.runCanceled:
+		r.Equal(ErrShutdownDeadline, first.runCause)
+	case <-time.After(time.Second):
+		r.Fail("context of run isn't canceled")
+	}
+	select {
+	case <-second.runCanceled:
+		r.Equal(task.ErrTaskShutdown, second.runCause)
+	case <-time.After(time.Second):
+		r.Fail("context of run isn't canceled")
+	}
+}
+
+func TestChildOperator_ShutdownDeadline(t *testing.T) {
+	r := require.New(t)
+
+	childTask := NewContextTask("childTask", task.Config{})
+	child := NewChildOperator("child", childTask)
+	tCh := make(chan error)
+	go func() {
+		tCh <- child.Run(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	// child operator and Shutdown are finished by the same deadline, so result of Shutdown can be any
+	_ = child.Shutdown(ctx)
+	select {
+	case err := <-tCh:
+		r.True(errors.Is(err, ErrShutdownDeadline))
+	case <-time.After(500 * time.Millisecond):
+		r.Fail("Not shutdowned in expected time")
+	}
+}
+
+func TestOperator_WithTracer(t *testing.T) {
+	r := require.New(t)
+
+	tracer := trace.NewInMemoryTracer()
+	first := NewRestartableTask("first", task.Config{})
+	op := NewOperator(first).WithTracer(tracer)
+	r.Equal(tracer, op.tracer)
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	waitFor(t, func() bool {
+		return first.getRunCount() == 1
+	})
+	go func() {
+		op.sigCh <- syscall.SIGTERM
+	}()
+	select {
+	case err := <-tCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("Not shutdowned in expected time")
+	}
+	waitFor(t, func() bool {
+		return len(tracer.Spans()) == 2
+	})
+	names := []string{tracer.Spans()[0].Name, tracer.Spans()[1].Name}
+	r.ElementsMatch([]string{"task.attempt", "task.shutdown"}, names)
+}
+
+func TestOperator_Run_ContextDone(t *testing.T) {
+	r := require.New(t)
+
+	j := &journal{}
+	db := NewJournalTask("db", j)
+	api := NewJournalTask("api", j, "db")
+	op := NewOperator(db, api).WithShutdownSignals(nil)
+	expectedCause := errors.New("expected cause")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(ctx)
+	}()
+	waitFor(t, op.IsReady)
+
+	cancel(expectedCause)
+	select {
+	case err := <-tCh:
+		var stopErr *StopError
+		r.True(errors.As(err, &stopErr))
+		r.Equal(StopReasonContextDone, stopErr.Reason)
+		r.True(errors.Is(err, expectedCause))
+		r.False(stopErr.DeadlineExceeded)
+		r.Equal(ExitCodeOK, ExitCode(err))
+	case <-time.After(time.Second):
+		r.Fail("operator isn't stopped")
+	}
+	// tasks are stopped gracefully in reverse order of dependencies
+	r.Equal([]string{"run db", "run api", "shutdown api", "shutdown db"}, j.get())
+}
+
+func TestOperator_Run_AllTasksCompleted(t *testing.T) {
+	r := require.New(t)
+
+	first := NewRestartableTask("first", task.Config{})
+	second := NewRestartableTask("second", task.Config{})
+	op := NewOperator(first, second).WithShutdownSignals(nil)
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	waitFor(t, op.IsReady)
+
+	first.stopCh <- struct{}{}
+	waitFor(t, func() bool {
+		return op.GetTaskSnapshots()["first"].Status == task.StatusStopped
+	})
+	// operator keeps running while some task isn't completed
+	select {
+	case err := <-tCh:
+		r.Failf("operator is stopped", "err: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	second.stopCh <- struct{}{}
+	select {
+	case err := <-tCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("operator isn't stopped")
+	}
+}
+
+func TestOperator_Run_CompletionStop(t *testing.T) {
+	r := require.New(t)
+
+	job := NewRestartableTask("job", task.Config{CompletionPolicy: task.CompletionStop})
+	server := NewRestartableTask("server", task.Config{})
+	op := NewOperator(job, server).WithShutdownSignals(nil)
+	events, unsubscribe := op.Subscribe(20)
+	defer unsubscribe()
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	waitFor(t, op.IsReady)
+
+	job.stopCh <- struct{}{}
+	select {
+	case err := <-tCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("operator isn't stopped")
+	}
+	waitFor(t, func() bool {
+		return op.GetTaskSnapshots()["server"].Status == task.StatusStopped
+	})
+	for e := range events {
+		if e.Type == EventShutdownStarted {
+			r.Equal("job", e.TaskID)
+			return
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package gomultitask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+const defaultReloadTimeout = 30 * time.Second
+
+var _ task.Reloader = (*Operator)(nil)
+
+// ReloadError is error returned by reload of task
+type ReloadError struct {
+	TaskID string
+	Err    error
+}
+
+// Error implements error
+func (e *ReloadError) Error() string {
+	return fmt.Sprintf("reload task ID %s: %s", e.TaskID, e.Err.Error())
+}
+
+// Unwrap return error of task
+func (e *ReloadError) Unwrap() error {
+	return e.Err
+}
+
+// WithReloadSignals set signals which reload tasks, by default reload signals aren't caught,
+// for example WithReloadSignals([]os.Signal{syscall.SIGHUP})
+func (o *Operator) WithReloadSignals(signals []os.Signal) *Operator {
+	o.reloadSignals = signals
+	return o
+}
+
+// WithReloadTimeout set timeout of reload of tasks, default is 30s
+func (o *Operator) WithReloadTimeout(duration time.Duration) *Operator {
+	o.reloadTimeout = duration
+	return o
+}
+
+// WithReloadRestartFallback enable restart of running tasks which don't implement task.Reloader,
+// by default such tasks are skipped
+func (o *Operator) WithReloadRestartFallback(enabled bool) *Operator {
+	o.reloadRestartFallback = enabled
+	return o
+}
+
+// Reload reload running tasks concurrently with reload timeout and wait results,
+// it returns errors of tasks joined by errors.Join, each of them is *ReloadError,
+// tasks which aren't reloaded in time have error of context.
+// Operator implements task.Reloader, so child operator reloads its tasks too
+func (o *Operator) Reload(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, o.reloadTimeout)
+	defer cancel()
+	startedAt := time.Now()
+	tasks := make([]*task.Task, 0)
+	for _, t := range o.getTaskList() {
+		if status := t.GetState().GetStatus(); status == task.StatusRunning || status == task.StatusStarting {
+			tasks = append(tasks, t)
+		}
+	}
+	var mu sync.Mutex
+	errs := make([]error, 0)
+	pending := make(map[*task.Task]struct{}, len(tasks))
+	for _, t := range tasks {
+		pending[t] = struct{}{}
+	}
+	var wg sync.WaitGroup
+	for _, t := range tasks {
+		wg.Add(1)
+		go func(t *task.Task) {
+			defer wg.Done()
+			err := o.reloadTask(ctx, t)
+			mu.Lock()
+			defer mu.Unlock()
+			if _, ok := pending[t]; !ok {
+				// result after timeout is already reported
+				return
+			}
+			delete(pending, t)
+			if err != nil {
+				o.logError("Reload of task failed", LogKeyTaskID, t.GetID(), LogKeyError, err)
+				errs = append(errs, &ReloadError{TaskID: t.GetID(), Err: err})
+			}
+		}(t)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	mu.Lock()
+	for _, t := range tasks {
+		if _, ok := pending[t]; !ok {
+			continue
+		}
+		delete(pending, t)
+		o.logError("Reload of task isn't finished in time", LogKeyTaskID, t.GetID(), LogKeyError, ctx.Err())
+		errs = append(errs, &ReloadError{TaskID: t.GetID(), Err: ctx.Err()})
+	}
+	err := errors.Join(errs...)
+	o.logInfo("Reload finished", "errors", len(errs), LogKeyDuration, time.Since(startedAt))
+	mu.Unlock()
+	o.events.publish(Event{Type: EventReloadCompleted, Err: err})
+	return err
+}
+
+// reloadTask reload task or restart it if reload isn't supported and fallback is enabled
+func (o *Operator) reloadTask(ctx context.Context, t *task.Task) error {
+	if t.IsReloadable() {
+		o.logDebug("Reload task", LogKeyTaskID, t.GetID())
+		return t.Reload(ctx)
+	}
+	if !o.reloadRestartFallback {
+		return nil
+	}
+	o.logInfo("Task isn't reloadable, it will be restarted", LogKeyTaskID, t.GetID())
+	return t.Restart(ctx)
+}
+
+// handleReloadSignals reload tasks on reload signals while ctx isn't done
+func (o *Operator) handleReloadSignals(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-o.reloadCh:
+			o.logInfo("Reload signal caught", LogKeySignal, sig.String())
+			o.events.publish(Event{Type: EventReloadStarted, Signal: sig})
+			_ = o.Reload(ctx)
+		}
+	}
+}
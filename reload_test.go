@@ -0,0 +1,155 @@
+package gomultitask
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+type ReloadableTask struct {
+	*RestartableTask
+	reloadCount int64
+	reloadErr   error
+	// reload is blocked while hangCh isn't closed if it's set
+	hangCh chan struct{}
+}
+
+func NewReloadableTask(id string, reloadErr error) *ReloadableTask {
+	return &ReloadableTask{RestartableTask: NewRestartableTask(id, task.Config{}), reloadErr: reloadErr}
+}
+
+func (t *ReloadableTask) Reload(context.Context) error {
+	atomic.AddInt64(&t.reloadCount, 1)
+	if t.hangCh != nil {
+		<-t.hangCh
+	}
+	return t.reloadErr
+}
+
+func (t *ReloadableTask) getReloadCount() int64 {
+	return atomic.LoadInt64(&t.reloadCount)
+}
+
+func runOperator(t *testing.T, op *Operator) {
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	waitFor(t, op.IsReady)
+	t.Cleanup(func() {
+		_ = op.Shutdown(context.Background())
+		<-tCh
+	})
+}
+
+func TestOperator_Reload(t *testing.T) {
+	r := require.New(t)
+
+	expectedErr := errors.New("expected error")
+	reloadable := NewReloadableTask("reloadable", nil)
+	failed := NewReloadableTask("failed", expectedErr)
+	plain := NewRestartableTask("plain", task.Config{})
+	op := NewOperator(reloadable, failed, plain).WithShutdownSignals(nil)
+	runOperator(t, op)
+
+	err := op.Reload(context.Background())
+	r.True(errors.Is(err, expectedErr))
+	var reloadErr *ReloadError
+	r.True(errors.As(err, &reloadErr))
+	r.Equal("failed", reloadErr.TaskID)
+
+	r.Equal(int64(1), reloadable.getReloadCount())
+	r.Equal(int64(1), failed.getReloadCount())
+	// task which isn't reloadable is skipped
+	r.Equal(int64(1), plain.getRunCount())
+}
+
+func TestOperator_Reload_Timeout(t *testing.T) {
+	r := require.New(t)
+
+	reloadable := NewReloadableTask("reloadable", nil)
+	hanging := NewReloadableTask("hanging", nil)
+	hanging.hangCh = make(chan struct{})
+	defer close(hanging.hangCh)
+	op := NewOperator(reloadable, hanging).WithShutdownSignals(nil).WithReloadTimeout(20 * time.Millisecond)
+	runOperator(t, op)
+
+	resCh := make(chan error)
+	go func() {
+		resCh <- op.Reload(context.Background())
+	}()
+	select {
+	case err := <-resCh:
+		r.True(errors.Is(err, context.DeadlineExceeded))
+		var reloadErr *ReloadError
+		r.True(errors.As(err, &reloadErr))
+		r.Equal("hanging", reloadErr.TaskID)
+	case <-time.After(time.Second):
+		r.Fail("reload isn't finished by timeout")
+	}
+	r.Equal(int64(1), reloadable.getReloadCount())
+}
+
+func TestOperator_Reload_RestartFallback(t *testing.T) {
+	r := require.New(t)
+
+	reloadable := NewReloadableTask("reloadable", nil)
+	plain := NewRestartableTask("plain", task.Config{})
+	op := NewOperator(reloadable, plain).WithShutdownSignals(nil).WithReloadRestartFallback(true)
+	runOperator(t, op)
+
+	r.NoError(op.Reload(context.Background()))
+	r.Equal(int64(1), reloadable.getReloadCount())
+	r.Equal(int64(1), reloadable.getRunCount())
+	waitFor(t, func() bool {
+		return plain.getRunCount() == 2
+	})
+}
+
+func TestOperator_Reload_ChildOperator(t *testing.T) {
+	r := require.New(t)
+
+	reloadable := NewReloadableTask("reloadable", nil)
+	op := NewOperator(NewChildOperator("child", reloadable)).WithShutdownSignals(nil)
+	runOperator(t, op)
+
+	r.NoError(op.Reload(context.Background()))
+	r.Equal(int64(1), reloadable.getReloadCount())
+}
+
+func TestOperator_Reload_Signal(t *testing.T) {
+	r := require.New(t)
+
+	reloadable := NewReloadableTask("reloadable", nil)
+	op := NewOperator(reloadable).WithShutdownSignals(nil).WithReloadSignals([]os.Signal{syscall.SIGHUP})
+	events, unsubscribe := op.Subscribe(10)
+	defer unsubscribe()
+	runOperator(t, op)
+
+	r.NoError(syscall.Kill(syscall.Getpid(), syscall.SIGHUP))
+	waitFor(t, func() bool {
+		return reloadable.getReloadCount() == 1
+	})
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case e := <-events:
+			if e.Type != EventReloadCompleted {
+				continue
+			}
+			r.NoError(e.Err)
+			return
+		case <-deadline:
+			r.Fail("reload isn't completed")
+			return
+		}
+	}
+}
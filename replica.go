@@ -0,0 +1,114 @@
+package gomultitask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+var (
+	// ErrInvalidReplicas is returned when number of replicas is negative
+	ErrInvalidReplicas = errors.New("invalid number of replicas")
+	// ErrReplicaIDMismatch is returned when factory builds task with id which differs from id of replica
+	ErrReplicaIDMismatch = errors.New("id of task built by factory differs from id of replica")
+)
+
+// TaskFactory build instance of replicated task with derived id, GetID of task must return this id
+type TaskFactory func(id string) task.Interface
+
+// replicaSet is group of identical tasks built by one factory
+type replicaSet struct {
+	factory TaskFactory
+	number  int
+}
+
+// GetReplicaID return id of replica, replicas are numbered from 1, e.g. "consumer-1"
+func GetReplicaID(id string, number int) string {
+	return fmt.Sprintf("%s-%d", id, number)
+}
+
+// WithReplicas add replicas of task built by factory, it panics on error
+func (o *Operator) WithReplicas(id string, replicas int, factory TaskFactory) *Operator {
+	if err := o.AddReplicas(id, replicas, factory); err != nil {
+		panic(err)
+	}
+	return o
+}
+
+// AddReplicas add group of independent tasks built by factory with ids from GetReplicaID,
+// each replica is restarted individually, replicas are started at once if operator is running
+func (o *Operator) AddReplicas(id string, replicas int, factory TaskFactory) error {
+	if replicas < 0 {
+		return fmt.Errorf("replicas %d of task ID %s: %w", replicas, id, ErrInvalidReplicas)
+	}
+	o.replicasMu.Lock()
+	defer o.replicasMu.Unlock()
+	if _, ok := o.replicas[id]; ok {
+		return fmt.Errorf("replicas of task ID %s: %w", id, ErrTaskExists)
+	}
+	set := &replicaSet{factory: factory}
+	o.replicas[id] = set
+	if err := o.scaleReplicas(context.Background(), id, set, replicas); err != nil {
+		delete(o.replicas, id)
+		_ = o.scaleReplicas(context.Background(), id, set, 0)
+		return err
+	}
+	return nil
+}
+
+// ScaleReplicas change number of replicas, new replicas are added with next numbers,
+// replicas with the highest numbers are removed gracefully like by RemoveTask
+func (o *Operator) ScaleReplicas(ctx context.Context, id string, replicas int) error {
+	if replicas < 0 {
+		return fmt.Errorf("replicas %d of task ID %s: %w", replicas, id, ErrInvalidReplicas)
+	}
+	o.replicasMu.Lock()
+	defer o.replicasMu.Unlock()
+	set, ok := o.replicas[id]
+	if !ok {
+		return fmt.Errorf("replicas of task ID %s: %w", id, ErrTaskNotFound)
+	}
+	return o.scaleReplicas(ctx, id, set, replicas)
+}
+
+// GetReplicas return current number of replicas, 0 if there isn't such group
+func (o *Operator) GetReplicas(id string) int {
+	o.replicasMu.Lock()
+	defer o.replicasMu.Unlock()
+	if set, ok := o.replicas[id]; ok {
+		return set.number
+	}
+	return 0
+}
+
+// scaleReplicas add or remove replicas one by one, it must be called under lock of replicas
+func (o *Operator) scaleReplicas(ctx context.Context, id string, set *replicaSet, replicas int) error {
+	for set.number < replicas {
+		replicaID := GetReplicaID(id, set.number+1)
+		t := set.factory(replicaID)
+		if t.GetID() != replicaID {
+			return fmt.Errorf("replica ID %s, task ID %s: %w", replicaID, t.GetID(), ErrReplicaIDMismatch)
+		}
+		if err := o.AddTask(t); err != nil {
+			return err
+		}
+		set.number++
+	}
+	for set.number > replicas {
+		replicaID := GetReplicaID(id, set.number)
+		err := o.RemoveTask(ctx, replicaID)
+		if errors.Is(err, ErrTaskNotFound) {
+			err = nil
+		}
+		// replica is removed from operator even if its shutdown failed
+		if err == nil || !o.hasTaskID(replicaID) {
+			set.number--
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package gomultitask
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+type replicaFactory struct {
+	mu    sync.Mutex
+	tasks map[string]*RestartableTask
+}
+
+func (f *replicaFactory) build(id string) task.Interface {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := NewRestartableTask(id, task.Config{FallNumber: 1})
+	f.tasks[id] = t
+	return t
+}
+
+func (f *replicaFactory) get(id string) *RestartableTask {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tasks[id]
+}
+
+func TestGetReplicaID(t *testing.T) {
+	require.Equal(t, "consumer-3", GetReplicaID("consumer", 3))
+}
+
+func TestOperator_Replicas(t *testing.T) {
+	r := require.New(t)
+
+	f := &replicaFactory{tasks: make(map[string]*RestartableTask)}
+	op := NewOperator(NewRestartableTask("first", task.Config{})).
+		WithShutdownSignals(nil).
+		WithReplicas("consumer", 2, f.build)
+	r.Equal(2, op.GetReplicas("consumer"))
+	r.Equal([]string{"first", "consumer-1", "consumer-2"}, getIDs(op.getTaskList()))
+	r.True(errors.Is(op.AddReplicas("consumer", 1, f.build), ErrTaskExists))
+	r.True(errors.Is(op.AddReplicas("other", -1, f.build), ErrInvalidReplicas))
+	r.True(errors.Is(op.ScaleReplicas(context.Background(), "unknown", 1), ErrTaskNotFound))
+
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	waitFor(t, op.IsReady)
+
+	// replica is restarted individually
+	f.get("consumer-1").failCh <- errors.New("expected error")
+	waitFor(t, func() bool {
+		return f.get("consumer-1").getRunCount() == 2
+	})
+	r.Equal(int64(1), f.get("consumer-2").getRunCount())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	r.NoError(op.ScaleReplicas(ctx, "consumer", 4))
+	r.Equal(4, op.GetReplicas("consumer"))
+	waitFor(t, func() bool {
+		return f.get("consumer-4") != nil && f.get("consumer-4").getRunCount() == 1
+	})
+
+	r.NoError(op.ScaleReplicas(ctx, "consumer", 1))
+	r.Equal(1, op.GetReplicas("consumer"))
+	r.Equal([]string{"first", "consumer-1"}, getIDs(op.getTaskList()))
+
+	r.NoError(op.Shutdown(ctx))
+	select {
+	case err := <-tCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("operator isn't stopped")
+	}
+}
+
+func TestOperator_AddReplicas_Rollback(t *testing.T) {
+	r := require.New(t)
+
+	f := &replicaFactory{tasks: make(map[string]*RestartableTask)}
+	op := NewOperator(NewRestartableTask("consumer-2", task.Config{}))
+	r.True(errors.Is(op.AddReplicas("consumer", 3, f.build), ErrTaskExists))
+	r.Equal(0, op.GetReplicas("consumer"))
+	r.Equal([]string{"consumer-2"}, getIDs(op.getTaskList()))
+}
+
+func TestOperator_AddReplicas_IDMismatch(t *testing.T) {
+	r := require.New(t)
+
+	op := NewOperator()
+	err := op.AddReplicas("consumer", 2, func(string) task.Interface {
+		return NewRestartableTask("consumer", task.Config{})
+	})
+	r.True(errors.Is(err, ErrReplicaIDMismatch))
+	r.EqualError(err, "replica ID consumer-1, task ID consumer: "+ErrReplicaIDMismatch.Error())
+	r.Equal(0, op.GetReplicas("consumer"))
+	r.Empty(op.getTaskList())
+
+	// factory which uses derived id is accepted
+	f := &replicaFactory{tasks: make(map[string]*RestartableTask)}
+	r.NoError(op.AddReplicas("consumer", 2, f.build))
+	r.Equal([]string{"consumer-1", "consumer-2"}, getIDs(op.getTaskList()))
+}
+
+func TestOperator_ScaleReplicas_ShutdownErr(t *testing.T) {
+	r := require.New(t)
+
+	eErr := errors.New("expected error")
+	op := NewOperator().WithShutdownSignals(nil)
+	r.NoError(op.AddReplicas("consumer", 2, func(id string) task.Interface {
+		tt := NewTestingTask(id, task.Config{}, 0)
+		tt.shutdownErr = eErr
+		return tt
+	}))
+	runOperator(t, op)
+
+	// replica is removed even if its shutdown failed, so number of replicas is decreased
+	r.Equal(eErr, op.ScaleReplicas(context.Background(), "consumer", 1))
+	r.Equal(1, op.GetReplicas("consumer"))
+	r.Equal([]string{"consumer-1"}, getIDs(op.getTaskList()))
+
+	r.NoError(op.ScaleReplicas(context.Background(), "consumer", 2))
+	r.Equal([]string{"consumer-1", "consumer-2"}, getIDs(op.getTaskList()))
+}
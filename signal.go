@@ -0,0 +1,100 @@
+package gomultitask
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+const defaultEscalationDeadline = 5 * time.Second
+
+// ErrForcedShutdown is cause of cancel of tasks context when shutdown is forced by signal
+var ErrForcedShutdown = errors.New("graceful shutdown is forced")
+
+// EscalationStage is action of operator on repeated shutdown signal while graceful shutdown
+type EscalationStage int
+
+const (
+	// EscalationShortenDeadline limits rest of graceful shutdown by escalation deadline
+	EscalationShortenDeadline EscalationStage = iota + 1
+	// EscalationForceCancel cancels context of tasks at once without waiting of their shutdown
+	EscalationForceCancel
+	// EscalationExit exits process immediately with ExitCodeForced
+	EscalationExit
+)
+
+// String return name of stage
+func (s EscalationStage) String() string {
+	switch s {
+	case EscalationShortenDeadline:
+		return "shorten_deadline"
+	case EscalationForceCancel:
+		return "force_cancel"
+	case EscalationExit:
+		return "exit"
+	default:
+		return "unknown"
+	}
+}
+
+// WithSignalEscalation set actions for repeated shutdown signals, the first signal starts graceful shutdown,
+// the second one runs the first stage and so on, signals after the last stage are ignored,
+// default stages are EscalationForceCancel and EscalationExit
+func (o *Operator) WithSignalEscalation(stages ...EscalationStage) *Operator {
+	o.escalationStages = stages
+	return o
+}
+
+// WithEscalationDeadline set rest of graceful shutdown for EscalationShortenDeadline, default is 5s
+func (o *Operator) WithEscalationDeadline(duration time.Duration) *Operator {
+	o.escalationDeadline = duration
+	return o
+}
+
+// escalation of graceful shutdown by repeated signals
+type escalation struct {
+	// index of the next stage, it's -1 while shutdown signal isn't caught,
+	// e.g. shutdown is started by fail of task, so the first signal doesn't escalate it
+	stage  int
+	timer  *time.Timer
+	cancel context.CancelCauseFunc
+}
+
+// escalate run next stage of escalation, cancel stops graceful shutdown with cause
+func (o *Operator) escalate(sig os.Signal, e *escalation) {
+	if e.stage < 0 {
+		e.stage = 0
+		o.logInfo("Signal caught while shutdown, graceful shutdown continues", LogKeySignal, sig.String())
+		o.events.publish(Event{Type: EventSignalReceived, Signal: sig})
+		return
+	}
+	if e.stage >= len(o.escalationStages) {
+		o.logWarn("Signal caught while shutdown, escalation stages are over", LogKeySignal, sig.String())
+		o.events.publish(Event{Type: EventSignalReceived, Signal: sig})
+		return
+	}
+	stage := o.escalationStages[e.stage]
+	e.stage++
+	o.logWarn("Signal caught while shutdown, shutdown is escalated", LogKeySignal, sig.String(), LogKeyStage, stage.String())
+	o.events.publish(Event{Type: EventShutdownEscalated, Signal: sig, Stage: stage})
+	switch stage {
+	case EscalationShortenDeadline:
+		if e.timer == nil {
+			e.timer = time.AfterFunc(o.escalationDeadline, func() {
+				e.cancel(ErrShutdownDeadline)
+			})
+		}
+	case EscalationForceCancel:
+		e.cancel(ErrForcedShutdown)
+	case EscalationExit:
+		o.exit(ExitCodeForced)
+	}
+}
+
+// stop release resources of escalation
+func (e *escalation) stop() {
+	if e.timer != nil {
+		e.timer.Stop()
+	}
+}
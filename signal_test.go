@@ -0,0 +1,203 @@
+package gomultitask
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+// HangingTask hangs in shutdown, it's stopped only by cancel of context,
+// it ignores context and hangs in run too if ignoreCtx is set
+type HangingTask struct {
+	id        string
+	runCause  chan error
+	release   chan struct{}
+	ignoreCtx bool
+}
+
+func NewHangingTask(t *testing.T, id string) *HangingTask {
+	ht := &HangingTask{id: id, runCause: make(chan error, 1), release: make(chan struct{})}
+	t.Cleanup(func() {
+		close(ht.release)
+	})
+	return ht
+}
+
+func (t *HangingTask) Run(ctx context.Context) error {
+	if t.ignoreCtx {
+		<-t.release
+		return nil
+	}
+	<-ctx.Done()
+	t.runCause <- context.Cause(ctx)
+	return nil
+}
+
+func (t *HangingTask) Shutdown(context.Context) error {
+	<-t.release
+	return nil
+}
+
+func (t *HangingTask) GetTaskConfig() task.Config {
+	return task.Config{}
+}
+
+func (t *HangingTask) GetID() string {
+	return t.id
+}
+
+func TestEscalationStage_String(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("shorten_deadline", EscalationShortenDeadline.String())
+	r.Equal("force_cancel", EscalationForceCancel.String())
+	r.Equal("exit", EscalationExit.String())
+	r.Equal("unknown", EscalationStage(0).String())
+}
+
+func TestOperator_SignalEscalation_ForceCancel(t *testing.T) {
+	r := require.New(t)
+
+	hanging := NewHangingTask(t, "hanging")
+	op := NewOperator(hanging).WithShutdownDeadline(10 * time.Second)
+	events, unsubscribe := op.Subscribe(20)
+	defer unsubscribe()
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	waitFor(t, op.IsReady)
+
+	op.sigCh <- syscall.SIGTERM
+	op.sigCh <- syscall.SIGINT
+	select {
+	case err := <-tCh:
+		r.True(errors.Is(err, ErrForcedShutdown))
+		var stopErr *StopError
+		r.True(errors.As(err, &stopErr))
+		r.True(stopErr.Forced)
+		r.False(stopErr.DeadlineExceeded)
+		r.Equal([]string{"hanging"}, stopErr.UnfinishedTasks)
+		r.Equal(ExitCodeForced, ExitCode(err))
+	case <-time.After(time.Second):
+		r.Fail("shutdown isn't forced")
+	}
+	r.Equal(ErrForcedShutdown, <-hanging.runCause)
+	for e := range events {
+		if e.Type == EventShutdownEscalated {
+			r.Equal(EscalationForceCancel, e.Stage)
+			r.Equal(syscall.SIGINT, e.Signal)
+			return
+		}
+	}
+}
+
+func TestOperator_SignalEscalation_ShutdownBeforeSignal(t *testing.T) {
+	r := require.New(t)
+
+	hanging := NewHangingTask(t, "hanging")
+	op := NewOperator(hanging).WithShutdownDeadline(10 * time.Second)
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	waitFor(t, op.IsReady)
+
+	go func() {
+		_ = op.Shutdown(context.Background())
+	}()
+	waitFor(t, func() bool {
+		return op.GetTaskSnapshots()["hanging"].Status == task.StatusStopping
+	})
+	// the first signal doesn't escalate shutdown which isn't started by signal
+	op.sigCh <- syscall.SIGTERM
+	select {
+	case <-tCh:
+		r.Fail("shutdown is forced by the first signal")
+	case <-time.After(50 * time.Millisecond):
+	}
+	op.sigCh <- syscall.SIGTERM
+	select {
+	case err := <-tCh:
+		r.True(errors.Is(err, ErrForcedShutdown))
+	case <-time.After(time.Second):
+		r.Fail("shutdown isn't forced")
+	}
+	r.Equal(ErrForcedShutdown, <-hanging.runCause)
+}
+
+func TestOperator_SignalEscalation_Default(t *testing.T) {
+	r := require.New(t)
+
+	hanging := NewHangingTask(t, "hanging")
+	hanging.ignoreCtx = true
+	var exitCode int64 = -1
+	op := NewOperator(hanging).WithShutdownDeadline(10 * time.Second)
+	op.exit = func(code int) {
+		atomic.StoreInt64(&exitCode, int64(code))
+	}
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	waitFor(t, op.IsReady)
+
+	op.sigCh <- syscall.SIGTERM
+	op.sigCh <- syscall.SIGINT
+	// task ignores cancel of context, so the third signal exits process
+	op.sigCh <- syscall.SIGINT
+	select {
+	case err := <-tCh:
+		r.True(errors.Is(err, ErrForcedShutdown))
+		r.Equal(int64(ExitCodeForced), atomic.LoadInt64(&exitCode))
+	case <-time.After(time.Second):
+		r.Fail("process isn't exited")
+	}
+}
+
+func TestOperator_SignalEscalation_ShortenDeadlineAndExit(t *testing.T) {
+	r := require.New(t)
+
+	hanging := NewHangingTask(t, "hanging")
+	hanging.ignoreCtx = true
+	var exitCode int64 = -1
+	op := NewOperator(hanging).
+		WithShutdownDeadline(10*time.Second).
+		WithSignalEscalation(EscalationShortenDeadline, EscalationExit).
+		WithEscalationDeadline(20 * time.Millisecond)
+	op.exit = func(code int) {
+		atomic.StoreInt64(&exitCode, int64(code))
+	}
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	waitFor(t, op.IsReady)
+
+	startedAt := time.Now()
+	op.sigCh <- syscall.SIGTERM
+	op.sigCh <- syscall.SIGINT
+	waitFor(t, func() bool {
+		return op.GetTaskSnapshots()["hanging"].Status == task.StatusStopping && time.Since(startedAt) > 20*time.Millisecond
+	})
+	select {
+	case <-tCh:
+		r.Fail("operator is stopped while run of task isn't returned")
+	case <-time.After(50 * time.Millisecond):
+	}
+	op.sigCh <- syscall.SIGINT
+	select {
+	case err := <-tCh:
+		r.True(errors.Is(err, ErrShutdownDeadline))
+		r.Equal(int64(ExitCodeForced), atomic.LoadInt64(&exitCode))
+	case <-time.After(time.Second):
+		r.Fail("process isn't exited")
+	}
+}
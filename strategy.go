@@ -0,0 +1,72 @@
+package gomultitask
+
+import (
+	"context"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+// Strategy of supervising, it defines which tasks will be restarted when one of them falls
+type Strategy int
+
+const (
+	// StrategyOneForOne restart only fallen task, it's default strategy
+	StrategyOneForOne Strategy = iota
+	// StrategyOneForAll restart fallen task and all other tasks
+	StrategyOneForAll
+	// StrategyRestForOne restart fallen task and all tasks started after it
+	StrategyRestForOne
+)
+
+// String return name of strategy
+func (s Strategy) String() string {
+	switch s {
+	case StrategyOneForOne:
+		return "one_for_one"
+	case StrategyOneForAll:
+		return "one_for_all"
+	case StrategyRestForOne:
+		return "rest_for_one"
+	default:
+		return "unknown"
+	}
+}
+
+// getSiblingsForRestart return tasks which must be restarted together with fallen task,
+// fallen task restarts itself
+func (o *Operator) getSiblingsForRestart(id string) []*task.Task {
+	tasks := o.getTaskList()
+	fallenIdx := -1
+	for i, t := range tasks {
+		if t.GetID() == id {
+			fallenIdx = i
+			break
+		}
+	}
+	if fallenIdx < 0 {
+		return nil
+	}
+	res := make([]*task.Task, 0)
+	for i, t := range tasks {
+		switch {
+		case i == fallenIdx:
+			continue
+		case o.strategy == StrategyOneForAll:
+			res = append(res, t)
+		case o.strategy == StrategyRestForOne && i > fallenIdx:
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+func (o *Operator) restartSiblings(ctx context.Context, id string) {
+	for _, t := range o.getSiblingsForRestart(id) {
+		go func(t *task.Task) {
+			o.logInfo("Restart task after fall of sibling", LogKeyTaskID, t.GetID(), "fallen_task_id", id)
+			if err := t.Restart(ctx); err != nil {
+				o.logError("Restart of task failed", LogKeyTaskID, t.GetID(), LogKeyError, err)
+			}
+		}(t)
+	}
+}
@@ -0,0 +1,149 @@
+package gomultitask
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+type RestartableTask struct {
+	id       string
+	cfg      task.Config
+	runCount int64
+	failCh   chan error
+	stopCh   chan struct{}
+}
+
+func NewRestartableTask(id string, cfg task.Config) *RestartableTask {
+	return &RestartableTask{
+		id:     id,
+		cfg:    cfg,
+		failCh: make(chan error),
+		stopCh: make(chan struct{}, 1),
+	}
+}
+
+func (t *RestartableTask) Run(context.Context) error {
+	atomic.AddInt64(&t.runCount, 1)
+	select {
+	case err := <-t.failCh:
+		return err
+	case <-t.stopCh:
+		return nil
+	}
+}
+
+func (t *RestartableTask) Shutdown(context.Context) error {
+	select {
+	case t.stopCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (t *RestartableTask) GetTaskConfig() task.Config {
+	return t.cfg
+}
+
+func (t *RestartableTask) GetID() string {
+	return t.id
+}
+
+func (t *RestartableTask) getRunCount() int64 {
+	return atomic.LoadInt64(&t.runCount)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			require.Fail(t, "condition isn't reached in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStrategy_String(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("one_for_one", StrategyOneForOne.String())
+	r.Equal("one_for_all", StrategyOneForAll.String())
+	r.Equal("rest_for_one", StrategyRestForOne.String())
+	r.Equal("unknown", Strategy(100).String())
+}
+
+func TestOperator_getSiblingsForRestart(t *testing.T) {
+	r := require.New(t)
+
+	tasks := getPreparedTask(t, 3)
+	op := NewOperator(tasks[0], tasks[1], tasks[2])
+	r.Empty(op.getSiblingsForRestart("testingTask1"))
+
+	op.WithStrategy(StrategyOneForAll)
+	r.Equal([]*task.Task{op.tasks[0], op.tasks[2]}, op.getSiblingsForRestart("testingTask1"))
+
+	op.WithStrategy(StrategyRestForOne)
+	r.Equal([]*task.Task{op.tasks[2]}, op.getSiblingsForRestart("testingTask1"))
+	r.Empty(op.getSiblingsForRestart("testingTask2"))
+	r.Empty(op.getSiblingsForRestart("unknown"))
+}
+
+func testStrategy(t *testing.T, strategy Strategy, expectedRuns []int64) {
+	r := require.New(t)
+
+	tasks := make([]task.Interface, 0)
+	rTasks := make([]*RestartableTask, 0)
+	for _, id := range []string{"first", "second", "third"} {
+		rTask := NewRestartableTask(id, task.Config{FallNumber: -1})
+		rTasks = append(rTasks, rTask)
+		tasks = append(tasks, rTask)
+	}
+	op := NewOperator(tasks...).WithStrategy(strategy)
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	// restart is applied to running tasks only
+	waitFor(t, op.IsReady)
+	rTasks[1].failCh <- errors.New("expected error")
+	waitFor(t, func() bool {
+		for i, rTask := range rTasks {
+			if rTask.getRunCount() != expectedRuns[i] {
+				return false
+			}
+		}
+		return true
+	})
+
+	go func() {
+		op.sigCh <- syscall.SIGTERM
+	}()
+	select {
+	case err := <-tCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("Not shutdowned in expected time")
+	}
+	for i, rTask := range rTasks {
+		r.Equal(expectedRuns[i], rTask.getRunCount())
+	}
+}
+
+func TestStrategyOneForOne(t *testing.T) {
+	testStrategy(t, StrategyOneForOne, []int64{1, 2, 1})
+}
+
+func TestStrategyOneForAll(t *testing.T) {
+	testStrategy(t, StrategyOneForAll, []int64{2, 2, 2})
+}
+
+func TestStrategyRestForOne(t *testing.T) {
+	testStrategy(t, StrategyRestForOne, []int64{1, 2, 2})
+}
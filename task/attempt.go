@@ -0,0 +1,63 @@
+package task
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrAttemptFinished is cause of cancel of attempt context when Run of user's task is returned
+	ErrAttemptFinished = errors.New("run attempt is finished")
+	// ErrTaskShutdown is cause of cancel of attempt context when task is shut down
+	ErrTaskShutdown = errors.New("task is shut down")
+	// ErrTaskRestart is cause of cancel of attempt context when task is restarted by supervisor
+	ErrTaskRestart = errors.New("task is restarted")
+)
+
+// startAttempt derive context of run attempt, it's canceled with cause by cancelAttempt,
+// context is canceled at once if shutdown is already requested, request of restart of previous attempt is forgotten
+func (t *Task) startAttempt(ctx context.Context) (context.Context, context.CancelCauseFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	t.attemptMu.Lock()
+	defer t.attemptMu.Unlock()
+	t.attemptCancel = cancel
+	t.state.ResetRestartRequested()
+	if t.state.IsShutdownRequested() {
+		cancel(ErrTaskShutdown)
+	}
+	return ctx, cancel
+}
+
+// finishAttempt forget cancel of run attempt when Run of user's task is returned
+func (t *Task) finishAttempt() {
+	t.attemptMu.Lock()
+	defer t.attemptMu.Unlock()
+	t.attemptCancel = nil
+}
+
+// requestRestart register request of restart if run attempt is live and return its cancel,
+// it returns false if task isn't running, for example it waits restart or dependencies
+func (t *Task) requestRestart() (context.CancelCauseFunc, bool) {
+	t.attemptMu.Lock()
+	defer t.attemptMu.Unlock()
+	if t.attemptCancel == nil {
+		return nil, false
+	}
+	t.state.SetRestartRequested()
+	return t.attemptCancel, true
+}
+
+// getAttemptCancel return cancel of current run attempt, nil if task isn't running,
+// cancel of finished attempt is noop, so it can be called after shutdown of attempt safely
+func (t *Task) getAttemptCancel() context.CancelCauseFunc {
+	t.attemptMu.Lock()
+	defer t.attemptMu.Unlock()
+	return t.attemptCancel
+}
+
+// cancelAttempt cancel context of run attempt after its shutdown
+func cancelAttempt(cancel context.CancelCauseFunc, cause error) {
+	if cancel != nil {
+		cancel(cause)
+	}
+}
@@ -0,0 +1,87 @@
+package task
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+const defaultBackoffMultiplier = 2
+
+// Backoff calculates delay before the next restart of task
+type Backoff interface {
+	// Next return delay before restart,
+	//  attempt - number of restart since start or last reset, starts from 1
+	//  prev - delay returned for previous attempt, 0 for the first one
+	Next(attempt int, prev time.Duration) time.Duration
+}
+
+// ConstantBackoff always return the same delay
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+// Next implements Backoff
+func (b ConstantBackoff) Next(int, time.Duration) time.Duration {
+	return b.Delay
+}
+
+// ExponentialBackoff multiplies delay on each attempt
+type ExponentialBackoff struct {
+	// delay for the first attempt
+	Initial time.Duration
+	// <= 0 - unlimited
+	Max time.Duration
+	// <= 1 - use default multiplier 2
+	Multiplier float64
+	// part of delay which will be randomized, from 0 to 1,
+	// for example 0.2 return delay in range [0.8*d, 1.2*d]
+	Jitter float64
+}
+
+// Next implements Backoff
+func (b ExponentialBackoff) Next(attempt int, _ time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	multiplier := b.Multiplier
+	if multiplier <= 1 {
+		multiplier = defaultBackoffMultiplier
+	}
+	delay := float64(b.Initial) * math.Pow(multiplier, float64(attempt-1))
+	if jitter := math.Min(math.Max(b.Jitter, 0), 1); jitter > 0 {
+		delay += delay * jitter * (2*rand.Float64() - 1)
+	}
+	return capDelay(delay, b.Max)
+}
+
+// DecorrelatedJitterBackoff is "decorrelated jitter" from AWS architecture blog,
+// delay is random value between Base and tripled previous delay
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration
+	// <= 0 - unlimited
+	Max time.Duration
+}
+
+// Next implements Backoff
+func (b DecorrelatedJitterBackoff) Next(_ int, prev time.Duration) time.Duration {
+	if prev < b.Base {
+		prev = b.Base
+	}
+	upper := 3 * float64(prev)
+	delay := float64(b.Base) + rand.Float64()*(upper-float64(b.Base))
+	return capDelay(delay, b.Max)
+}
+
+func capDelay(delay float64, max time.Duration) time.Duration {
+	if max > 0 && delay > float64(max) {
+		return max
+	}
+	if delay > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	if delay < 0 {
+		return 0
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,66 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConstantBackoff_Next(t *testing.T) {
+	r := require.New(t)
+
+	b := ConstantBackoff{Delay: time.Second}
+	r.Equal(time.Second, b.Next(1, 0))
+	r.Equal(time.Second, b.Next(10, time.Second))
+}
+
+func TestExponentialBackoff_Next(t *testing.T) {
+	r := require.New(t)
+
+	b := ExponentialBackoff{Initial: time.Millisecond, Max: 10 * time.Millisecond}
+	r.Equal(time.Millisecond, b.Next(1, 0))
+	r.Equal(2*time.Millisecond, b.Next(2, 0))
+	r.Equal(8*time.Millisecond, b.Next(4, 0))
+	r.Equal(10*time.Millisecond, b.Next(5, 0))
+	r.Equal(10*time.Millisecond, b.Next(1000, 0))
+
+	b.Multiplier = 3
+	r.Equal(9*time.Millisecond, b.Next(3, 0))
+}
+
+func TestExponentialBackoff_NextUnlimited(t *testing.T) {
+	r := require.New(t)
+
+	b := ExponentialBackoff{Initial: time.Second}
+	r.Equal(time.Duration(1<<63-1), b.Next(100, 0))
+}
+
+func TestExponentialBackoff_NextJitter(t *testing.T) {
+	r := require.New(t)
+
+	b := ExponentialBackoff{Initial: 100 * time.Millisecond, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		d := b.Next(2, 0)
+		r.True(d >= 100*time.Millisecond && d <= 300*time.Millisecond, d.String())
+	}
+}
+
+func TestDecorrelatedJitterBackoff_Next(t *testing.T) {
+	r := require.New(t)
+
+	b := DecorrelatedJitterBackoff{Base: 10 * time.Millisecond, Max: time.Second}
+	var prev time.Duration
+	for i := 1; i <= 100; i++ {
+		d := b.Next(i, prev)
+		upper := 3 * prev
+		if upper < 3*b.Base {
+			upper = 3 * b.Base
+		}
+		if upper > b.Max {
+			upper = b.Max
+		}
+		r.True(d >= b.Base && d <= upper, d.String())
+		prev = d
+	}
+}
@@ -0,0 +1,28 @@
+package task
+
+// CompletionPolicy defines what happens when run of user's task returns nil without shutdown request
+type CompletionPolicy int
+
+const (
+	// CompletionIgnore leave task stopped, another tasks keep running, it's default policy,
+	// operator is stopped when all its tasks are completed
+	CompletionIgnore CompletionPolicy = iota
+	// CompletionRestart run task again after RestartTimeout, it isn't counted like fall
+	CompletionRestart
+	// CompletionStop stop operator successfully
+	CompletionStop
+)
+
+// String return name of policy
+func (p CompletionPolicy) String() string {
+	switch p {
+	case CompletionIgnore:
+		return "ignore"
+	case CompletionRestart:
+		return "restart"
+	case CompletionStop:
+		return "stop"
+	default:
+		return "unknown"
+	}
+}
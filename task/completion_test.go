@@ -0,0 +1,16 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletionPolicy_String(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("ignore", CompletionIgnore.String())
+	r.Equal("restart", CompletionRestart.String())
+	r.Equal("stop", CompletionStop.String())
+	r.Equal("unknown", CompletionPolicy(100).String())
+}
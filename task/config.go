@@ -10,8 +10,36 @@ type Config struct {
 	// == 0 - stop all after first err
 	// >  0 - stop after fail number > FallNumber
 	FallNumber int
+	// <= 0 - FallNumber limits falls for all lifetime of task
+	// >  0 - FallNumber limits falls within sliding window, like MaxR/MaxT in Erlang
+	FallWindow time.Duration
 	// <= 0 - use like no timeout
 	RestartTimeout time.Duration
+	// nil - use RestartTimeout for every restart
+	Backoff Backoff
+	// <= 0 - never reset backoff,
+	// >  0 - reset backoff attempts if task was running at least this duration before fall
+	BackoffResetAfter time.Duration
+	// ids of tasks which must be ready before start of task,
+	// task is stopped before its dependencies while shutdown
+	DependsOn []string
+	// false - task is ready right after start
+	// true  - task calls task.NotifyReady(ctx) when it's initialized
+	NotifyReady bool
+	// <= 0 - wait readiness without limit
+	// >  0 - task falls with ErrNotReadyInTime if it isn't ready in time, used only with NotifyReady
+	ReadyTimeout time.Duration
+	// <= 0 - task has all shutdown deadline of operator
+	// >  0 - timeout of Shutdown of task, it can't be longer than shutdown deadline of operator
+	ShutdownTimeout time.Duration
+	// <= 0 - watchdog is disabled
+	// >  0 - task calls task.Heartbeat(ctx) at least once per timeout,
+	// otherwise context of attempt is canceled and task falls with ErrHeartbeatTimeout
+	HeartbeatTimeout time.Duration
+	// what happens when task panics, by default panic is counted like fall
+	PanicPolicy PanicPolicy
+	// what happens when task completes successfully, by default task stays stopped
+	CompletionPolicy CompletionPolicy
 }
 
 // GetDefaultConfig return default set config
@@ -27,7 +55,43 @@ func (tc *Config) FallNumberIsUnlimited() bool {
 	return tc.FallNumber < 0
 }
 
+// HasFallWindow return true if falls are counted within sliding window
+func (tc *Config) HasFallWindow() bool {
+	return tc.FallWindow > 0
+}
+
 // HasRestartTimeout return info about timeout
 func (tc *Config) HasRestartTimeout() bool {
 	return tc.RestartTimeout > 0
 }
+
+// HasBackoffReset return info about resetting of backoff after healthy running
+func (tc *Config) HasBackoffReset() bool {
+	return tc.BackoffResetAfter > 0
+}
+
+// HasReadyTimeout return info about limit of waiting readiness
+func (tc *Config) HasReadyTimeout() bool {
+	return tc.NotifyReady && tc.ReadyTimeout > 0
+}
+
+// HasShutdownTimeout return info about own shutdown timeout of task
+func (tc *Config) HasShutdownTimeout() bool {
+	return tc.ShutdownTimeout > 0
+}
+
+// HasHeartbeatTimeout return info about watchdog of task
+func (tc *Config) HasHeartbeatTimeout() bool {
+	return tc.HeartbeatTimeout > 0
+}
+
+// GetRestartDelay return delay before restart with number attempt
+func (tc *Config) GetRestartDelay(attempt int, prev time.Duration) time.Duration {
+	if tc.Backoff != nil {
+		return tc.Backoff.Next(attempt, prev)
+	}
+	if tc.HasRestartTimeout() {
+		return tc.RestartTimeout
+	}
+	return 0
+}
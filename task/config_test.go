@@ -33,3 +33,60 @@ func TestConfig_HasRestartTimeout(t *testing.T) {
 	cfg.RestartTimeout = 1
 	r.True(cfg.HasRestartTimeout())
 }
+
+func TestConfig_GetRestartDelay(t *testing.T) {
+	r := require.New(t)
+
+	cfg := GetDefaultConfig()
+	r.Equal(time.Duration(0), cfg.GetRestartDelay(1, 0))
+	cfg.RestartTimeout = time.Second
+	r.Equal(time.Second, cfg.GetRestartDelay(5, 0))
+	cfg.Backoff = ExponentialBackoff{Initial: time.Millisecond}
+	r.Equal(4*time.Millisecond, cfg.GetRestartDelay(3, 0))
+}
+
+func TestConfig_HasBackoffReset(t *testing.T) {
+	r := require.New(t)
+
+	cfg := GetDefaultConfig()
+	r.False(cfg.HasBackoffReset())
+	cfg.BackoffResetAfter = time.Second
+	r.True(cfg.HasBackoffReset())
+}
+
+func TestConfig_HasFallWindow(t *testing.T) {
+	r := require.New(t)
+
+	cfg := GetDefaultConfig()
+	r.False(cfg.HasFallWindow())
+	cfg.FallWindow = time.Second
+	r.True(cfg.HasFallWindow())
+}
+
+func TestConfig_HasReadyTimeout(t *testing.T) {
+	r := require.New(t)
+
+	cfg := GetDefaultConfig()
+	cfg.ReadyTimeout = time.Second
+	r.False(cfg.HasReadyTimeout())
+	cfg.NotifyReady = true
+	r.True(cfg.HasReadyTimeout())
+}
+
+func TestConfig_HasShutdownTimeout(t *testing.T) {
+	r := require.New(t)
+
+	cfg := GetDefaultConfig()
+	r.False(cfg.HasShutdownTimeout())
+	cfg.ShutdownTimeout = time.Second
+	r.True(cfg.HasShutdownTimeout())
+}
+
+func TestConfig_HasHeartbeatTimeout(t *testing.T) {
+	r := require.New(t)
+
+	cfg := GetDefaultConfig()
+	r.False(cfg.HasHeartbeatTimeout())
+	cfg.HeartbeatTimeout = time.Second
+	r.True(cfg.HasHeartbeatTimeout())
+}
@@ -0,0 +1,50 @@
+package task
+
+import "time"
+
+// EventType is type of task lifecycle event
+type EventType int
+
+const (
+	// EventStarting - run attempt of task is starting
+	EventStarting EventType = iota + 1
+	// EventReady - task became ready
+	EventReady
+	// EventFailed - run attempt of task returned error, it's fall of task
+	EventFailed
+	// EventRestarting - task will be run again after Delay
+	EventRestarting
+	// EventStopped - task won't be run again, Err is the last error of task
+	EventStopped
+)
+
+// String return name of event type
+func (t EventType) String() string {
+	switch t {
+	case EventStarting:
+		return "starting"
+	case EventReady:
+		return "ready"
+	case EventFailed:
+		return "failed"
+	case EventRestarting:
+		return "restarting"
+	case EventStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// Event of task lifecycle
+type Event struct {
+	Type       EventType
+	Time       time.Time
+	ID         string
+	FallNumber int
+	Err        error
+	Delay      time.Duration
+}
+
+// Listener receives events of task, it's called synchronously, so it mustn't block
+type Listener func(Event)
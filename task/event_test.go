@@ -0,0 +1,18 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventType_String(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("starting", EventStarting.String())
+	r.Equal("ready", EventReady.String())
+	r.Equal("failed", EventFailed.String())
+	r.Equal("restarting", EventRestarting.String())
+	r.Equal("stopped", EventStopped.String())
+	r.Equal("unknown", EventType(0).String())
+}
@@ -0,0 +1,75 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrHeartbeatTimeout is fall reason of task which didn't send heartbeat in HeartbeatTimeout
+var ErrHeartbeatTimeout = errors.New("task missed heartbeat")
+
+type heartbeatKey struct{}
+
+// Heartbeat notify watchdog that task, which was run with ctx, is alive.
+// It's used by tasks with HeartbeatTimeout in config, for another tasks and foreign contexts it's noop.
+func Heartbeat(ctx context.Context) {
+	if beat, ok := ctx.Value(heartbeatKey{}).(func()); ok {
+		beat()
+	}
+}
+
+func withHeartbeat(ctx context.Context, beat func()) context.Context {
+	return context.WithValue(ctx, heartbeatKey{}, beat)
+}
+
+// watchdog of one run attempt of task, it calls onTimeout once if heartbeat isn't received in time
+type watchdog struct {
+	mu       sync.Mutex
+	timeout  time.Duration
+	timer    *time.Timer
+	fired    bool
+	finished bool
+}
+
+func startWatchdog(timeout time.Duration, onTimeout func()) *watchdog {
+	w := &watchdog{timeout: timeout}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timer = time.AfterFunc(timeout, func() {
+		if w.setFired() {
+			onTimeout()
+		}
+	})
+	return w
+}
+
+// beat postpone timeout
+func (w *watchdog) beat() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.fired || w.finished {
+		return
+	}
+	w.timer.Reset(w.timeout)
+}
+
+func (w *watchdog) setFired() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.finished {
+		return false
+	}
+	w.fired = true
+	return true
+}
+
+// finish attempt and return true if it was stopped by watchdog
+func (w *watchdog) finish() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.finished = true
+	w.timer.Stop()
+	return w.fired
+}
@@ -1,6 +1,9 @@
 package task
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
 // Interface of run in system task
 type Interface interface {
@@ -13,3 +16,12 @@ type Interface interface {
 	// return id of task, it will be use for beauty logs
 	GetID() string
 }
+
+// ErrReloadNotSupported is returned by reload of task which isn't Reloader
+var ErrReloadNotSupported = errors.New("reload isn't supported")
+
+// Reloader is optional interface of task which can reload its configuration without restart
+type Reloader interface {
+	// reload task function, it's called while task is running
+	Reload(context.Context) error
+}
@@ -0,0 +1,60 @@
+package task
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicPolicy defines what happens when user's task panics
+type PanicPolicy int
+
+const (
+	// PanicAsFall recover panic and count it like fall, task is restarted by restart policy, it's default policy
+	PanicAsFall PanicPolicy = iota
+	// PanicAsFatal recover panic and stop task without restarts, operator is stopped
+	PanicAsFatal
+	// PanicRepanic recover panic, send events and panic again with *PanicError, it crashes process
+	PanicRepanic
+)
+
+// String return name of policy
+func (p PanicPolicy) String() string {
+	switch p {
+	case PanicAsFall:
+		return "fall"
+	case PanicAsFatal:
+		return "fatal"
+	case PanicRepanic:
+		return "repanic"
+	default:
+		return "unknown"
+	}
+}
+
+// PanicError is error of run attempt which panicked
+type PanicError struct {
+	// value passed to panic
+	Value interface{}
+	// stack trace of goroutine at the moment of panic
+	Stack []byte
+}
+
+func newPanicError(value interface{}) *PanicError {
+	return &PanicError{
+		Value: value,
+		Stack: debug.Stack(),
+	}
+}
+
+// Error implements error
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in run: %v", e.Value)
+}
+
+// Unwrap return value of panic if it's error
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
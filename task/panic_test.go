@@ -0,0 +1,29 @@
+package task
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPanicPolicy_String(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("fall", PanicAsFall.String())
+	r.Equal("fatal", PanicAsFatal.String())
+	r.Equal("repanic", PanicRepanic.String())
+	r.Equal("unknown", PanicPolicy(100).String())
+}
+
+func TestPanicError(t *testing.T) {
+	r := require.New(t)
+
+	valueErr := errors.New("expected error")
+	err := newPanicError(valueErr)
+	r.Equal("panic in run: expected error", err.Error())
+	r.True(errors.Is(err, valueErr))
+	r.Contains(string(err.Stack), "newPanicError")
+
+	r.Nil(newPanicError("expected panic").Unwrap())
+}
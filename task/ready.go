@@ -0,0 +1,62 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNotReadyInTime is fall reason of task which isn't ready in ReadyTimeout
+var ErrNotReadyInTime = errors.New("task isn't ready in time")
+
+type readyNotifierKey struct{}
+
+// NotifyReady notify operator that task, which was run with ctx, is initialized and ready.
+// It's used by tasks with NotifyReady in config, for another tasks and foreign contexts it's noop.
+func NotifyReady(ctx context.Context) {
+	if notify, ok := ctx.Value(readyNotifierKey{}).(func()); ok {
+		notify()
+	}
+}
+
+func withReadyNotifier(ctx context.Context, notify func()) context.Context {
+	return context.WithValue(ctx, readyNotifierKey{}, notify)
+}
+
+// readiness of one run attempt of task
+type readiness struct {
+	mu       sync.Mutex
+	ready    bool
+	timedOut bool
+	finished bool
+}
+
+// setReady return true if attempt became ready
+func (r *readiness) setReady() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.finished || r.timedOut {
+		return false
+	}
+	r.ready = true
+	return true
+}
+
+// setTimedOut return true if attempt isn't ready in time and must be stopped
+func (r *readiness) setTimedOut() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.finished || r.ready {
+		return false
+	}
+	r.timedOut = true
+	return true
+}
+
+// finish attempt and return true if it was stopped by ready timeout
+func (r *readiness) finish() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finished = true
+	return r.timedOut
+}
@@ -1,46 +1,332 @@
 package task
 
-// State of task
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// State of task, it's safe for concurrent use,
+// status of task is changed only by allowed transitions, see Status.CanTransit
 type State struct {
+	mu                sync.RWMutex
+	status            Status
+	changedAt         time.Time
+	transitions       map[Status]time.Time
 	fallNumber        int
-	failed            bool
+	fallTimes         []time.Time
 	shutdownRequested bool
+	shutdownCh        chan struct{}
+	restartRequested  bool
+	ready             bool
+	readyCh           chan struct{}
+	readyClosed       bool
+	running           bool
+	startNumber       int
+	startedAt         time.Time
+	lastErrorAt       time.Time
+	lastErr           error
+	restartAt         time.Time
 }
 
 // GetDefaultState build default state
 func GetDefaultState() *State {
+	now := time.Now()
 	return &State{
-		failed:     false,
-		fallNumber: 0,
+		status:      StatusPending,
+		changedAt:   now,
+		transitions: map[Status]time.Time{StatusPending: now},
+		fallNumber:  0,
+		shutdownCh:  make(chan struct{}),
+		readyCh:     make(chan struct{}),
+	}
+}
+
+// Reset state to default, it mustn't be called while task is running
+func (s *State) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.status = StatusPending
+	s.changedAt = now
+	s.transitions = map[Status]time.Time{StatusPending: now}
+	s.fallNumber = 0
+	s.fallTimes = nil
+	s.shutdownRequested = false
+	s.shutdownCh = make(chan struct{})
+	s.restartRequested = false
+	s.ready = false
+	s.readyCh = make(chan struct{})
+	s.readyClosed = false
+	s.running = false
+	s.startNumber = 0
+	s.startedAt = time.Time{}
+	s.lastErrorAt = time.Time{}
+	s.lastErr = nil
+	s.restartAt = time.Time{}
+}
+
+// SetStatus change status of task, it returns ErrInvalidTransition if transition isn't allowed
+func (s *State) SetStatus(status Status) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.setStatus(status)
+}
+
+func (s *State) setStatus(status Status) error {
+	if !s.status.CanTransit(status) {
+		return fmt.Errorf("from %s to %s: %w", s.status, status, ErrInvalidTransition)
+	}
+	now := time.Now()
+	s.status = status
+	s.changedAt = now
+	s.transitions[status] = now
+	return nil
+}
+
+// SetWaitingRestart change status to StatusWaitingRestart, next run attempt will be after delay
+func (s *State) SetWaitingRestart(delay time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.setStatus(StatusWaitingRestart); err != nil {
+		return err
+	}
+	s.restartAt = s.changedAt.Add(delay)
+	return nil
+}
+
+// GetRestartAt return time of the next run attempt, zero time if task doesn't wait restart
+func (s *State) GetRestartAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.status != StatusWaitingRestart {
+		return time.Time{}
+	}
+	return s.restartAt
+}
+
+// GetStatus return current status of task
+func (s *State) GetStatus() Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// Snapshot return consistent copy of state
+func (s *State) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	transitions := make(map[Status]time.Time, len(s.transitions))
+	for status, at := range s.transitions {
+		transitions[status] = at
+	}
+	var restartAt time.Time
+	if s.status == StatusWaitingRestart {
+		restartAt = s.restartAt
+	}
+	return Snapshot{
+		Status:        s.status,
+		ChangedAt:     s.changedAt,
+		Transitions:   transitions,
+		Ready:         s.ready,
+		FallNumber:    s.fallNumber,
+		RestartNumber: s.getRestartNumber(),
+		Uptime:        s.getUptime(),
+		LastErrorAt:   s.lastErrorAt,
+		LastError:     s.lastErr,
+		RestartAt:     restartAt,
 	}
 }
 
 // FallNumberInc add one fall to state
 func (s *State) FallNumberInc() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.fallNumber++
 }
 
 // GetFallNumber return falls number
 func (s *State) GetFallNumber() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.fallNumber
 }
 
+// FallInWindow register fall in sliding window and return falls number within last window duration,
+// falls out of window are forgotten
+func (s *State) FallInWindow(window time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.fallTimes = append(s.fallTimes, now)
+	border := now.Add(-window)
+	i := 0
+	for i < len(s.fallTimes) && s.fallTimes[i].Before(border) {
+		i++
+	}
+	s.fallTimes = s.fallTimes[i:]
+	return len(s.fallTimes)
+}
+
 // SetFailed register that task was failed and don't need shutdown it
 func (s *State) SetFailed() {
-	s.failed = true
+	_ = s.SetStatus(StatusFailed)
 }
 
-// IsFailed return failed status
+// IsFailed return true if status is StatusFailed
 func (s *State) IsFailed() bool {
-	return s.failed
+	return s.GetStatus() == StatusFailed
 }
 
 // SetShutdownRequested register request of shutdown.
 func (s *State) SetShutdownRequested() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.shutdownRequested {
+		close(s.shutdownCh)
+	}
 	s.shutdownRequested = true
 }
 
 // IsShutdownRequested return state of shutdown.
 func (s *State) IsShutdownRequested() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.shutdownRequested
 }
+
+// ShutdownRequested return channel which is closed when shutdown is requested
+func (s *State) ShutdownRequested() <-chan struct{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.shutdownCh
+}
+
+// SetRestartRequested register request of restart by supervisor.
+func (s *State) SetRestartRequested() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restartRequested = true
+}
+
+// ResetRestartRequested forget request of restart, it's satisfied by new run.
+func (s *State) ResetRestartRequested() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restartRequested = false
+}
+
+// IsRestartRequested return state of restart.
+func (s *State) IsRestartRequested() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.restartRequested
+}
+
+// SetReady register that task is ready, dependent tasks can be started
+func (s *State) SetReady() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ready = true
+	if !s.readyClosed {
+		close(s.readyCh)
+		s.readyClosed = true
+	}
+}
+
+// SetNotReady register that task isn't ready now, for example it's restarting
+func (s *State) SetNotReady() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ready = false
+}
+
+// IsReady return current readiness of task
+func (s *State) IsReady() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ready
+}
+
+// Ready return channel which is closed when task is ready first time
+func (s *State) Ready() <-chan struct{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.readyCh
+}
+
+// SetStarted register start of run attempt
+func (s *State) SetStarted() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = true
+	s.startNumber++
+	s.startedAt = time.Now()
+}
+
+// SetFinished register end of run attempt, err is result of attempt
+func (s *State) SetFinished(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+	if err != nil {
+		s.lastErrorAt = time.Now()
+		s.lastErr = err
+	}
+}
+
+// IsRunning return true if run attempt isn't finished
+func (s *State) IsRunning() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.running
+}
+
+// IsStarted return true if task was started at least once
+func (s *State) IsStarted() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.startNumber > 0
+}
+
+// GetRestartNumber return number of run attempts after the first one
+func (s *State) GetRestartNumber() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.getRestartNumber()
+}
+
+func (s *State) getRestartNumber() int {
+	if s.startNumber == 0 {
+		return 0
+	}
+	return s.startNumber - 1
+}
+
+// GetUptime return duration of current run attempt, 0 if task isn't running
+func (s *State) GetUptime() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.getUptime()
+}
+
+func (s *State) getUptime() time.Duration {
+	if !s.running {
+		return 0
+	}
+	return time.Since(s.startedAt)
+}
+
+// GetLastErrorAt return time of last error returned by task, zero time if there wasn't errors
+func (s *State) GetLastErrorAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastErrorAt
+}
+
+// GetLastError return last error returned by task, nil if there weren't errors
+func (s *State) GetLastError() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastErr
+}
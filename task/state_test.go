@@ -1,7 +1,9 @@
 package task
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -31,6 +33,169 @@ func TestState_IsFailed(t *testing.T) {
 
 	state := GetDefaultState()
 	r.False(state.IsFailed())
+	r.NoError(state.SetStatus(StatusStarting))
 	state.SetFailed()
 	r.True(state.IsFailed())
 }
+
+func TestState_FallInWindow(t *testing.T) {
+	r := require.New(t)
+
+	state := GetDefaultState()
+	state.fallTimes = []time.Time{time.Now().Add(-time.Hour), time.Now().Add(-time.Minute)}
+	r.Equal(2, state.FallInWindow(10*time.Minute))
+	r.Len(state.fallTimes, 2)
+	r.Equal(2, state.FallInWindow(time.Second))
+	r.Len(state.fallTimes, 2)
+}
+
+func TestState_SetReady(t *testing.T) {
+	r := require.New(t)
+
+	state := GetDefaultState()
+	select {
+	case <-state.Ready():
+		r.Fail("state mustn't be ready")
+	default:
+	}
+	r.False(state.IsReady())
+	state.SetReady()
+	state.SetReady()
+	r.True(state.IsReady())
+	select {
+	case <-state.Ready():
+	default:
+		r.Fail("state must be ready")
+	}
+	state.SetNotReady()
+	r.False(state.IsReady())
+}
+
+func TestState_Run(t *testing.T) {
+	r := require.New(t)
+
+	state := GetDefaultState()
+	r.False(state.IsStarted())
+	r.False(state.IsRunning())
+	r.Equal(time.Duration(0), state.GetUptime())
+
+	state.SetStarted()
+	r.True(state.IsStarted())
+	r.True(state.IsRunning())
+	r.Equal(0, state.GetRestartNumber())
+	time.Sleep(time.Millisecond)
+	r.True(state.GetUptime() > 0)
+
+	state.SetFinished(errors.New("expected error"))
+	r.False(state.IsRunning())
+	r.WithinDuration(time.Now(), state.GetLastErrorAt(), time.Second)
+	state.SetStarted()
+	r.Equal(1, state.GetRestartNumber())
+}
+
+func TestState_Reset(t *testing.T) {
+	r := require.New(t)
+
+	state := GetDefaultState()
+	state.FallNumberInc()
+	r.NoError(state.SetStatus(StatusStarting))
+	state.SetFailed()
+	state.SetReady()
+	state.SetStarted()
+	state.SetFinished(errors.New("expected error"))
+	state.Reset()
+	r.Equal(0, state.GetFallNumber())
+	r.False(state.IsFailed())
+	r.Equal(StatusPending, state.GetStatus())
+	r.Nil(state.GetLastError())
+	r.False(state.IsReady())
+	r.False(state.IsStarted())
+	select {
+	case <-state.Ready():
+		r.Fail("state mustn't be ready")
+	default:
+	}
+}
+
+func TestState_SetStatus(t *testing.T) {
+	r := require.New(t)
+
+	state := GetDefaultState()
+	r.Equal(StatusPending, state.GetStatus())
+	r.NoError(state.SetStatus(StatusStarting))
+	r.NoError(state.SetStatus(StatusRunning))
+	r.NoError(state.SetStatus(StatusStopping))
+	err := state.SetStatus(StatusRunning)
+	r.True(errors.Is(err, ErrInvalidTransition))
+	r.Equal("from stopping to running: invalid transition of task status", err.Error())
+	r.Equal(StatusStopping, state.GetStatus())
+	r.NoError(state.SetStatus(StatusStopped))
+}
+
+func TestState_Snapshot(t *testing.T) {
+	r := require.New(t)
+
+	state := GetDefaultState()
+	r.NoError(state.SetStatus(StatusStarting))
+	state.SetStarted()
+	state.SetReady()
+	r.NoError(state.SetStatus(StatusRunning))
+	state.FallNumberInc()
+	expectedErr := errors.New("expected error")
+	state.SetFinished(expectedErr)
+	r.NoError(state.SetStatus(StatusRestarting))
+	state.SetStarted()
+
+	snapshot := state.Snapshot()
+	r.Equal(StatusRestarting, snapshot.Status)
+	r.Equal(snapshot.Transitions[StatusRestarting], snapshot.ChangedAt)
+	r.Len(snapshot.Transitions, 4)
+	r.False(snapshot.Transitions[StatusRunning].After(snapshot.ChangedAt))
+	r.False(snapshot.Transitions[StatusStarting].After(snapshot.Transitions[StatusRunning]))
+	r.True(snapshot.Ready)
+	r.Equal(1, snapshot.FallNumber)
+	r.Equal(1, snapshot.RestartNumber)
+	r.Equal(expectedErr, snapshot.LastError)
+	r.False(snapshot.LastErrorAt.IsZero())
+
+	// snapshot is copy
+	snapshot.Transitions[StatusFailed] = time.Now()
+	r.NotContains(state.Snapshot().Transitions, StatusFailed)
+}
+
+func TestState_SetWaitingRestart(t *testing.T) {
+	r := require.New(t)
+
+	state := GetDefaultState()
+	r.True(errors.Is(state.SetWaitingRestart(time.Second), ErrInvalidTransition))
+	r.True(state.GetRestartAt().IsZero())
+	r.NoError(state.SetStatus(StatusStarting))
+	r.NoError(state.SetStatus(StatusRestarting))
+	r.NoError(state.SetWaitingRestart(time.Second))
+	r.WithinDuration(time.Now().Add(time.Second), state.GetRestartAt(), 100*time.Millisecond)
+	r.Equal(state.GetRestartAt(), state.Snapshot().RestartAt)
+	r.NoError(state.SetStatus(StatusStarting))
+	r.True(state.GetRestartAt().IsZero())
+	r.True(state.Snapshot().RestartAt.IsZero())
+}
+
+func TestState_ShutdownRequested(t *testing.T) {
+	r := require.New(t)
+
+	state := GetDefaultState()
+	ch := state.ShutdownRequested()
+	select {
+	case <-ch:
+		r.Fail("shutdown isn't requested")
+	default:
+	}
+	state.SetShutdownRequested()
+	state.SetShutdownRequested()
+	<-ch
+	state.Reset()
+	select {
+	case <-state.ShutdownRequested():
+		r.Fail("shutdown isn't requested after reset")
+	default:
+	}
+}
@@ -0,0 +1,113 @@
+package task
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidTransition is returned when state of task can't be changed to requested status
+var ErrInvalidTransition = errors.New("invalid transition of task status")
+
+// Status of task in its lifecycle
+type Status int
+
+const (
+	// StatusPending - task isn't started yet or waits its dependencies
+	StatusPending Status = iota
+	// StatusStarting - run attempt is started, task isn't ready yet
+	StatusStarting
+	// StatusRunning - run attempt is started and task is ready
+	StatusRunning
+	// StatusRestarting - run attempt is finished, task will be run again
+	StatusRestarting
+	// StatusWaitingRestart - task fell and waits delay before the next run attempt
+	StatusWaitingRestart
+	// StatusStopping - shutdown of task is requested
+	StatusStopping
+	// StatusStopped - task is stopped and won't be run again
+	StatusStopped
+	// StatusFailed - task reached fall limit and won't be run again
+	StatusFailed
+)
+
+// Statuses is list of all statuses of task
+var Statuses = []Status{
+	StatusPending,
+	StatusStarting,
+	StatusRunning,
+	StatusRestarting,
+	StatusWaitingRestart,
+	StatusStopping,
+	StatusStopped,
+	StatusFailed,
+}
+
+// String return name of status
+func (s Status) String() string {
+	switch s {
+	case StatusPending:
+		return "pending"
+	case StatusStarting:
+		return "starting"
+	case StatusRunning:
+		return "running"
+	case StatusRestarting:
+		return "restarting"
+	case StatusWaitingRestart:
+		return "waiting_restart"
+	case StatusStopping:
+		return "stopping"
+	case StatusStopped:
+		return "stopped"
+	case StatusFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// IsFinal return true if task won't be run again without reset of state
+func (s Status) IsFinal() bool {
+	return s == StatusStopped || s == StatusFailed
+}
+
+// transitions are allowed changes of status, reset to StatusPending is allowed from any status
+var transitions = map[Status][]Status{
+	StatusPending:        {StatusStarting, StatusStopping, StatusStopped},
+	StatusStarting:       {StatusRunning, StatusRestarting, StatusStopping, StatusStopped, StatusFailed},
+	StatusRunning:        {StatusRestarting, StatusStopping, StatusStopped, StatusFailed},
+	StatusRestarting:     {StatusStarting, StatusWaitingRestart, StatusStopping, StatusStopped, StatusFailed},
+	StatusWaitingRestart: {StatusStarting, StatusStopping, StatusStopped, StatusFailed},
+	StatusStopping:       {StatusStopped, StatusFailed},
+	StatusStopped:        {},
+	StatusFailed:         {},
+}
+
+// CanTransit return true if status can be changed from s to next
+func (s Status) CanTransit(next Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot is consistent copy of task state for status reporting
+type Snapshot struct {
+	Status Status
+	// time of last change of status
+	ChangedAt time.Time
+	// time of the last entering in each status, statuses which weren't entered are absent
+	Transitions   map[Status]time.Time
+	Ready         bool
+	FallNumber    int
+	RestartNumber int
+	// duration of current run attempt, 0 if task isn't running
+	Uptime time.Duration
+	// zero time and nil if there weren't errors
+	LastErrorAt time.Time
+	LastError   error
+	// time of the next run attempt, zero time if status isn't StatusWaitingRestart
+	RestartAt time.Time
+}
@@ -0,0 +1,50 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus_String(t *testing.T) {
+	r := require.New(t)
+
+	names := make([]string, 0, len(Statuses))
+	for _, status := range Statuses {
+		names = append(names, status.String())
+	}
+	r.Equal([]string{"pending", "starting", "running", "restarting", "waiting_restart", "stopping", "stopped", "failed"}, names)
+	r.Equal("unknown", Status(100).String())
+}
+
+func TestStatus_IsFinal(t *testing.T) {
+	r := require.New(t)
+
+	r.True(StatusStopped.IsFinal())
+	r.True(StatusFailed.IsFinal())
+	r.False(StatusRunning.IsFinal())
+	r.False(StatusStopping.IsFinal())
+}
+
+func TestStatus_CanTransit(t *testing.T) {
+	r := require.New(t)
+
+	r.True(StatusPending.CanTransit(StatusStarting))
+	r.True(StatusStarting.CanTransit(StatusRunning))
+	r.True(StatusRunning.CanTransit(StatusRestarting))
+	r.True(StatusRestarting.CanTransit(StatusStarting))
+	r.True(StatusRestarting.CanTransit(StatusWaitingRestart))
+	r.True(StatusWaitingRestart.CanTransit(StatusStarting))
+	r.True(StatusWaitingRestart.CanTransit(StatusStopping))
+	r.True(StatusRunning.CanTransit(StatusStopping))
+	r.True(StatusStopping.CanTransit(StatusStopped))
+	r.True(StatusStopping.CanTransit(StatusFailed))
+
+	r.False(StatusPending.CanTransit(StatusRunning))
+	r.False(StatusStopping.CanTransit(StatusRunning))
+	r.False(StatusStopping.CanTransit(StatusRestarting))
+	r.False(StatusStopping.CanTransit(StatusWaitingRestart))
+	r.False(StatusStopped.CanTransit(StatusStarting))
+	r.False(StatusFailed.CanTransit(StatusStopped))
+	r.False(StatusRunning.CanTransit(StatusRunning))
+}
@@ -3,8 +3,18 @@ package task
 import (
 	"context"
 	"errors"
-	"fmt"
+	"sync"
 	"time"
+
+	"github.com/andrskom/gomultitask/trace"
+)
+
+// reasons of shutdown of user's task, they are used in tracing
+const (
+	shutdownReasonShutdown     = "shutdown"
+	shutdownReasonRestart      = "restart"
+	shutdownReasonReadyTimeout = "ready_timeout"
+	shutdownReasonHeartbeat    = "heartbeat_timeout"
 )
 
 // Task is system wrapper for task
@@ -13,8 +23,14 @@ type Task struct {
 	cfg           Config
 	runF          func(context.Context) error
 	shutDownF     func(context.Context) error
+	reloadF       func(context.Context) error
 	state         *State
 	notHandledErr chan<- Err
+	listener      Listener
+	tracer        trace.Tracer
+
+	attemptMu     sync.Mutex
+	attemptCancel context.CancelCauseFunc
 }
 
 // Err is internal task error
@@ -28,14 +44,19 @@ type Err struct {
 //  notHandledErr - is channel for send custom err while we can restart application
 //  i - user's task
 func NewFromInterface(notHandledErr chan<- Err, i Interface) *Task {
-	return &Task{
+	t := &Task{
 		notHandledErr: notHandledErr,
 		id:            i.GetID(),
 		cfg:           i.GetTaskConfig(),
 		runF:          i.Run,
 		shutDownF:     i.Shutdown,
 		state:         GetDefaultState(),
+		tracer:        trace.NoopTracer{},
+	}
+	if r, ok := i.(Reloader); ok {
+		t.reloadF = r.Reload
 	}
+	return t
 }
 
 func (t *Task) sendNotHandledErr(err error) {
@@ -49,40 +70,353 @@ func (t *Task) sendNotHandledErr(err error) {
 // Run task with restart while not reached fall limit or exit
 func (t *Task) Run(ctx context.Context) (err error) {
 	defer func() {
-		if rec := recover(); rec != nil {
-			err = fmt.Errorf("panic in run: %+v", rec)
+		if !t.state.IsFailed() {
+			_ = t.state.SetStatus(StatusStopped)
 		}
+		var panicErr *PanicError
+		if errors.As(err, &panicErr) && t.cfg.PanicPolicy == PanicRepanic {
+			t.emit(Event{Type: EventStopped, Err: err})
+			panic(panicErr)
+		}
+		t.emit(Event{Type: EventStopped, Err: err})
 	}()
+	var attempt, restartAttempt int
+	var restartDelay time.Duration
 	for {
 		if t.state.IsShutdownRequested() {
 			return errors.New("try to rerun when shutdown requested")
 		}
-		if err := t.runF(ctx); err != nil {
-			t.state.FallNumberInc()
-			if t.cfg.FallNumberIsUnlimited() || t.state.GetFallNumber() <= t.cfg.FallNumber {
+		attempt++
+		startedAt := time.Now()
+		err := t.runAttempt(ctx, attempt)
+		if t.state.IsShutdownRequested() {
+			// error of task after shutdown request isn't fall, task is stopped
+			return err
+		}
+		if t.state.IsRestartRequested() {
+			t.state.ResetRestartRequested()
+			if !t.setRestarting() {
+				return err
+			}
+			t.emit(Event{Type: EventRestarting})
+			continue
+		}
+		if err != nil {
+			canRestart := t.registerFall() && !t.isFatal(err)
+			t.emit(Event{Type: EventFailed, Err: err})
+			if canRestart {
+				if !t.setRestarting() {
+					return err
+				}
 				t.sendNotHandledErr(err)
-				if t.cfg.HasRestartTimeout() {
-					time.Sleep(t.cfg.RestartTimeout)
+				if t.cfg.HasBackoffReset() && time.Since(startedAt) >= t.cfg.BackoffResetAfter {
+					restartAttempt, restartDelay = 0, 0
+				}
+				restartAttempt++
+				restartDelay = t.cfg.GetRestartDelay(restartAttempt, restartDelay)
+				t.emit(Event{Type: EventRestarting, Err: err, Delay: restartDelay})
+				if ok, err := t.waitRestart(ctx, attempt, restartDelay); !ok {
+					return err
 				}
 				continue
 			}
 			t.state.SetFailed()
 			return err
 		}
+		if t.cfg.CompletionPolicy == CompletionRestart {
+			var delay time.Duration
+			if t.cfg.HasRestartTimeout() {
+				delay = t.cfg.RestartTimeout
+			}
+			if !t.setRestarting() {
+				return nil
+			}
+			t.emit(Event{Type: EventRestarting, Delay: delay})
+			if ok, err := t.waitRestart(ctx, attempt, delay); !ok {
+				return err
+			}
+			continue
+		}
 		break
 	}
 	return nil
 }
 
-// Shutdown task, is failed, don't need to stop it
+// setRestarting change status to StatusRestarting, it returns false if transition isn't allowed,
+// e.g. shutdown is requested concurrently, so task mustn't be restarted
+func (t *Task) setRestarting() bool {
+	return t.state.SetStatus(StatusRestarting) == nil
+}
+
+// waitRestart wait delay before next run attempt, it returns false if waiting is interrupted
+// by shutdown of task or by done of ctx, err is cause of ctx in the last case
+func (t *Task) waitRestart(ctx context.Context, attempt int, delay time.Duration) (ok bool, err error) {
+	_, span := t.tracer.Start(
+		ctx,
+		"task.restart_wait",
+		trace.String(trace.KeyTaskID, t.id),
+		trace.Int(trace.KeyAttempt, attempt),
+		trace.Int(trace.KeyFallNumber, t.state.GetFallNumber()),
+		trace.Int64(trace.KeyRestartDelay, int64(delay)),
+	)
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+	if delay <= 0 {
+		select {
+		case <-t.state.ShutdownRequested():
+			return false, nil
+		case <-ctx.Done():
+			return false, context.Cause(ctx)
+		default:
+			return true, nil
+		}
+	}
+	_ = t.state.SetWaitingRestart(delay)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true, nil
+	case <-t.state.ShutdownRequested():
+		return false, nil
+	case <-ctx.Done():
+		return false, context.Cause(ctx)
+	}
+}
+
+// runAttempt run user's task once with own context and track its readiness,
+// context of attempt is canceled with cause ErrAttemptFinished when user's task is returned
+func (t *Task) runAttempt(ctx context.Context, attempt int) (err error) {
+	// task isn't started again if it's stopping
+	if err := t.state.SetStatus(StatusStarting); err != nil {
+		return err
+	}
+	ctx, span := t.tracer.Start(
+		ctx,
+		"task.attempt",
+		trace.String(trace.KeyTaskID, t.id),
+		trace.Int(trace.KeyAttempt, attempt),
+	)
+	ctx, cancel := t.startAttempt(ctx)
+	defer t.finishAttempt()
+	defer cancel(ErrAttemptFinished)
+	t.emit(Event{Type: EventStarting})
+	t.state.SetStarted()
+	defer func() {
+		t.state.SetNotReady()
+		t.state.SetFinished(err)
+		span.RecordError(err)
+		span.End()
+	}()
+	if t.cfg.HasHeartbeatTimeout() {
+		w := startWatchdog(t.cfg.HeartbeatTimeout, func() {
+			cancel(ErrHeartbeatTimeout)
+			// context of attempt is already canceled, shutdown has own timeout only
+			_ = t.callShutdown(context.WithoutCancel(ctx), shutdownReasonHeartbeat)
+		})
+		ctx = withHeartbeat(ctx, w.beat)
+		defer func() {
+			if w.finish() {
+				err = ErrHeartbeatTimeout
+			}
+		}()
+	}
+	if !t.cfg.NotifyReady {
+		t.setReady()
+		return t.callRun(ctx)
+	}
+	r := &readiness{}
+	ctx = withReadyNotifier(ctx, func() {
+		if r.setReady() {
+			t.setReady()
+		}
+	})
+	if t.cfg.HasReadyTimeout() {
+		timer := time.AfterFunc(t.cfg.ReadyTimeout, func() {
+			if r.setTimedOut() {
+				_ = t.callShutdown(ctx, shutdownReasonReadyTimeout)
+				cancel(ErrNotReadyInTime)
+			}
+		})
+		defer timer.Stop()
+	}
+	err = t.callRun(ctx)
+	if r.finish() {
+		return ErrNotReadyInTime
+	}
+	return err
+}
+
+// callRun call run function of user's task, panic is returned like *PanicError
+func (t *Task) callRun(ctx context.Context) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = newPanicError(rec)
+		}
+	}()
+	return t.runF(ctx)
+}
+
+// isFatal return true if task mustn't be restarted after err
+func (t *Task) isFatal(err error) bool {
+	var panicErr *PanicError
+	return errors.As(err, &panicErr) && t.cfg.PanicPolicy != PanicAsFall
+}
+
+func (t *Task) setReady() {
+	t.state.SetReady()
+	_ = t.state.SetStatus(StatusRunning)
+	t.emit(Event{Type: EventReady})
+}
+
+// registerFall count fall of task and return true if task can be restarted
+func (t *Task) registerFall() bool {
+	t.state.FallNumberInc()
+	if t.cfg.FallNumberIsUnlimited() {
+		return true
+	}
+	if t.cfg.HasFallWindow() {
+		return t.state.FallInWindow(t.cfg.FallWindow) <= t.cfg.FallNumber
+	}
+	return t.state.GetFallNumber() <= t.cfg.FallNumber
+}
+
+// Shutdown task, is failed, don't need to stop it,
+// context of current run attempt is canceled with cause ErrTaskShutdown after shutdown of user's task
 func (t *Task) Shutdown(ctx context.Context) error {
 	if t.state.IsFailed() {
 		return nil
 	}
 	t.state.SetShutdownRequested()
+	_ = t.state.SetStatus(StatusStopping)
+	cancel := t.getAttemptCancel()
+	defer cancelAttempt(cancel, ErrTaskShutdown)
+	return t.callShutdown(ctx, shutdownReasonShutdown)
+}
+
+// callShutdown call shutdown function of user's task with own shutdown timeout of task
+func (t *Task) callShutdown(ctx context.Context, reason string) (err error) {
+	ctx, span := t.tracer.Start(
+		ctx,
+		"task.shutdown",
+		trace.String(trace.KeyTaskID, t.id),
+		trace.String(trace.KeyShutdownReason, reason),
+	)
+	defer func() {
+		outcome := "ok"
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			outcome = "deadline_exceeded"
+		case err != nil:
+			outcome = "error"
+		}
+		span.SetAttributes(trace.String(trace.KeyShutdownOutcome, outcome))
+		span.RecordError(err)
+		span.End()
+	}()
+	if t.cfg.HasShutdownTimeout() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.cfg.ShutdownTimeout)
+		defer cancel()
+	}
 	return t.shutDownF(ctx)
 }
 
+// Restart stop current run of task by shutdown function, after that task will be run again,
+// restart isn't counted like fall, context of current run attempt is canceled with cause ErrTaskRestart,
+// it's noop if run attempt isn't live, for example while task waits restart
+func (t *Task) Restart(ctx context.Context) error {
+	if t.state.GetStatus().IsFinal() || t.state.IsShutdownRequested() {
+		return nil
+	}
+	cancel, ok := t.requestRestart()
+	if !ok {
+		return nil
+	}
+	defer cancelAttempt(cancel, ErrTaskRestart)
+	return t.callShutdown(ctx, shutdownReasonRestart)
+}
+
+// IsReloadable return true if user's task implements Reloader
+func (t *Task) IsReloadable() bool {
+	return t.reloadF != nil
+}
+
+// Reload call reload function of user's task, it returns ErrReloadNotSupported if task isn't Reloader
+func (t *Task) Reload(ctx context.Context) (err error) {
+	if t.reloadF == nil {
+		return ErrReloadNotSupported
+	}
+	ctx, span := t.tracer.Start(ctx, "task.reload", trace.String(trace.KeyTaskID, t.id))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+	return t.reloadF(ctx)
+}
+
+// SetListener set listener of task lifecycle events, it mustn't be called while task is running
+func (t *Task) SetListener(listener Listener) {
+	t.listener = listener
+}
+
+func (t *Task) emit(event Event) {
+	if t.listener == nil {
+		return
+	}
+	event.Time = time.Now()
+	event.ID = t.id
+	event.FallNumber = t.state.GetFallNumber()
+	t.listener(event)
+}
+
+// SetTracer set tracer of task lifecycle, it mustn't be called while task is running
+func (t *Task) SetTracer(tracer trace.Tracer) {
+	t.tracer = tracer
+}
+
+// ResetState forget falls and requests of previous run, it mustn't be called while task is running
+func (t *Task) ResetState() {
+	t.state.Reset()
+}
+
+// GetState return state of task, it's safe for concurrent use
+func (t *Task) GetState() *State {
+	return t.state
+}
+
+// IsReady return current readiness of task
+func (t *Task) IsReady() bool {
+	return t.state.IsReady()
+}
+
+// Ready return channel which is closed when task is ready first time
+func (t *Task) Ready() <-chan struct{} {
+	return t.state.Ready()
+}
+
+// GetDependsOn return ids of tasks which must be ready before start of task
+func (t *Task) GetDependsOn() []string {
+	return t.cfg.DependsOn
+}
+
+// GetFallNumber return number of falls of task
+func (t *Task) GetFallNumber() int {
+	return t.state.GetFallNumber()
+}
+
+// GetCompletionPolicy return what happens when task completes successfully
+func (t *Task) GetCompletionPolicy() CompletionPolicy {
+	return t.cfg.CompletionPolicy
+}
+
+// IsCompleted return true if task stopped successfully without shutdown request
+func (t *Task) IsCompleted() bool {
+	return t.state.GetStatus() == StatusStopped && !t.state.IsShutdownRequested()
+}
+
 // GetID return id of task
 func (t *Task) GetID() string {
 	return t.id
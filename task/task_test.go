@@ -3,10 +3,14 @@ package task
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/gomultitask/trace"
 )
 
 type TaskMock struct {
@@ -133,6 +137,99 @@ func TestTask_Run_FinishedReachedErrLimit(t *testing.T) {
 	}
 }
 
+func TestTask_Run_FallWindow(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = 1
+	cfg.FallWindow = time.Minute
+	m.On("GetTaskConfig").Return(cfg)
+	eErr := errors.New("expected err")
+	m.On("Run", mock.Anything).Return(eErr).Times(3)
+	m.On("Run", mock.Anything).Return(nil)
+	task := NewFromInterface(ch, m)
+	task.state.fallTimes = []time.Time{time.Now().Add(-time.Hour)}
+	err := task.Run(context.Background())
+	r.Error(err)
+	r.Equal(eErr, err)
+	r.Len(ch, 1)
+	r.Equal(2, task.state.GetFallNumber())
+}
+
+func TestTask_Run_FallWindowExpired(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = 1
+	cfg.FallWindow = time.Millisecond
+	cfg.RestartTimeout = 5 * time.Millisecond
+	m.On("GetTaskConfig").Return(cfg)
+	m.On("Run", mock.Anything).Return(errors.New("expected err")).Times(3)
+	m.On("Run", mock.Anything).Return(nil)
+	task := NewFromInterface(ch, m)
+	err := task.Run(context.Background())
+	r.NoError(err)
+	r.Len(ch, 3)
+	r.Equal(3, task.state.GetFallNumber())
+}
+
+type backoffRecorder struct {
+	attempts []int
+	prev     []time.Duration
+}
+
+func (b *backoffRecorder) Next(attempt int, prev time.Duration) time.Duration {
+	b.attempts = append(b.attempts, attempt)
+	b.prev = append(b.prev, prev)
+	return time.Duration(attempt) * time.Millisecond
+}
+
+func TestTask_Run_Backoff(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	backoff := &backoffRecorder{}
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = 3
+	cfg.Backoff = backoff
+	m.On("GetTaskConfig").Return(cfg)
+	m.On("Run", mock.Anything).Return(errors.New("expected err")).Times(cfg.FallNumber)
+	m.On("Run", mock.Anything).Return(nil)
+	task := NewFromInterface(ch, m)
+	err := task.Run(context.Background())
+	r.NoError(err)
+	r.Equal([]int{1, 2, 3}, backoff.attempts)
+	r.Equal([]time.Duration{0, time.Millisecond, 2 * time.Millisecond}, backoff.prev)
+}
+
+func TestTask_Run_BackoffReset(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	backoff := &backoffRecorder{}
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = 3
+	cfg.Backoff = backoff
+	cfg.BackoffResetAfter = time.Nanosecond
+	m.On("GetTaskConfig").Return(cfg)
+	m.On("Run", mock.Anything).Return(errors.New("expected err")).Times(cfg.FallNumber)
+	m.On("Run", mock.Anything).Return(nil)
+	task := NewFromInterface(ch, m)
+	err := task.Run(context.Background())
+	r.NoError(err)
+	r.Equal([]int{1, 1, 1}, backoff.attempts)
+}
+
 func TestTask_Run_Panic(t *testing.T) {
 	r := require.New(t)
 
@@ -147,6 +244,62 @@ func TestTask_Run_Panic(t *testing.T) {
 	err := task.Run(context.Background())
 	r.Error(err)
 	r.Contains(err.Error(), "panic")
+	// panic is counted like fall by default
+	m.AssertNumberOfCalls(t, "Run", 4)
+	var panicErr *PanicError
+	r.True(errors.As(err, &panicErr))
+	r.Equal("expected panic", panicErr.Value)
+	r.Contains(string(panicErr.Stack), "TaskMock).Run")
+	r.True(task.state.IsFailed())
+}
+
+func TestTask_Run_PanicAsFatal(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{panicOnRun: true}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = 3
+	cfg.PanicPolicy = PanicAsFatal
+	m.On("GetTaskConfig").Return(cfg)
+	m.On("Run", mock.Anything).Return(nil)
+	task := NewFromInterface(ch, m)
+	err := task.Run(context.Background())
+	var panicErr *PanicError
+	r.True(errors.As(err, &panicErr))
+	m.AssertNumberOfCalls(t, "Run", 1)
+	r.True(task.state.IsFailed())
+}
+
+func TestTask_Run_Repanic(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{panicOnRun: true}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = 3
+	cfg.PanicPolicy = PanicRepanic
+	m.On("GetTaskConfig").Return(cfg)
+	m.On("Run", mock.Anything).Return(nil)
+	task := NewFromInterface(ch, m)
+	events := make([]EventType, 0)
+	task.SetListener(func(e Event) {
+		events = append(events, e.Type)
+	})
+	var rec interface{}
+	func() {
+		defer func() {
+			rec = recover()
+		}()
+		_ = task.Run(context.Background())
+	}()
+	panicErr, ok := rec.(*PanicError)
+	r.True(ok)
+	r.Equal("expected panic", panicErr.Value)
+	m.AssertNumberOfCalls(t, "Run", 1)
+	r.Equal([]EventType{EventStarting, EventReady, EventFailed, EventStopped}, events)
 }
 
 func TestTask_Shutdown(t *testing.T) {
@@ -181,6 +334,26 @@ func TestTask_Shutdown_Err(t *testing.T) {
 	r.Equal(eErr, err)
 }
 
+func TestTask_Shutdown_Timeout(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.ShutdownTimeout = time.Second
+	m.On("GetTaskConfig").Return(cfg)
+	var deadline time.Time
+	var hasDeadline bool
+	m.On("Shutdown", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		deadline, hasDeadline = args.Get(0).(context.Context).Deadline()
+	})
+	task := NewFromInterface(ch, m)
+	r.NoError(task.Shutdown(context.Background()))
+	r.True(hasDeadline)
+	r.WithinDuration(time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+}
+
 func TestTask_ShutdownFailed(t *testing.T) {
 	r := require.New(t)
 
@@ -193,7 +366,630 @@ func TestTask_ShutdownFailed(t *testing.T) {
 	eErr := errors.New("expected error")
 	m.On("Shutdown", mock.Anything).Return(eErr)
 	task := NewFromInterface(ch, m)
-	task.state.failed = true
+	task.state.status = StatusFailed
 	err := task.Shutdown(context.Background())
 	r.NoError(err)
 }
+
+func TestTask_Restart(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	m.On("GetTaskConfig").Return(GetDefaultConfig())
+	startedCh := make(chan struct{})
+	stopCh := make(chan struct{})
+	runCount := 0
+	m.On("Run", mock.Anything).Return(errors.New("stopped")).Run(func(mock.Arguments) {
+		runCount++
+		startedCh <- struct{}{}
+		<-stopCh
+	}).Times(2)
+	m.On("Run", mock.Anything).Return(nil)
+	m.On("Shutdown", mock.Anything).Return(nil).Run(func(mock.Arguments) {
+		stopCh <- struct{}{}
+	})
+	task := NewFromInterface(ch, m)
+	resCh := make(chan error)
+	go func() {
+		resCh <- task.Run(context.Background())
+	}()
+	// restart is applied to live run attempt only
+	<-startedCh
+	r.NoError(task.Restart(context.Background()))
+	<-startedCh
+	r.NoError(task.Restart(context.Background()))
+	r.NoError(<-resCh)
+	r.Equal(2, runCount)
+	r.Equal(0, task.state.GetFallNumber())
+	r.Len(ch, 0)
+}
+
+func TestTask_RestartFailed(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	m.On("GetTaskConfig").Return(GetDefaultConfig())
+	task := NewFromInterface(ch, m)
+	task.state.status = StatusFailed
+	r.NoError(task.Restart(context.Background()))
+	m.AssertNotCalled(t, "Shutdown", mock.Anything)
+}
+
+func TestTask_Run_NotifyReady(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.NotifyReady = true
+	m.On("GetTaskConfig").Return(cfg)
+	task := NewFromInterface(ch, m)
+	readyStates := make([]bool, 0)
+	m.On("Run", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		readyStates = append(readyStates, task.IsReady())
+		NotifyReady(args.Get(0).(context.Context))
+		readyStates = append(readyStates, task.IsReady())
+	})
+	err := task.Run(context.Background())
+	r.NoError(err)
+	r.Equal([]bool{false, true}, readyStates)
+	r.False(task.IsReady())
+	select {
+	case <-task.Ready():
+	default:
+		r.Fail("task must be ready")
+	}
+}
+
+func TestTask_Run_ReadyTimeout(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.NotifyReady = true
+	cfg.ReadyTimeout = 10 * time.Millisecond
+	m.On("GetTaskConfig").Return(cfg)
+	stopCh := make(chan struct{})
+	m.On("Run", mock.Anything).Return(nil).Run(func(mock.Arguments) {
+		<-stopCh
+	})
+	m.On("Shutdown", mock.Anything).Return(nil).Run(func(mock.Arguments) {
+		close(stopCh)
+	})
+	task := NewFromInterface(ch, m)
+	err := task.Run(context.Background())
+	r.Equal(ErrNotReadyInTime, err)
+	r.True(task.state.IsFailed())
+}
+
+func TestNotifyReady_ForeignContext(t *testing.T) {
+	require.NotPanics(t, func() {
+		NotifyReady(context.Background())
+	})
+}
+
+func TestTask_Run_HeartbeatTimeout(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = 1
+	cfg.HeartbeatTimeout = 20 * time.Millisecond
+	m.On("GetTaskConfig").Return(cfg)
+	var causes []error
+	m.On("Run", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		ctx := args.Get(0).(context.Context)
+		// hung task reacts only to cancel of context
+		<-ctx.Done()
+		causes = append(causes, context.Cause(ctx))
+	})
+	m.On("Shutdown", mock.Anything).Return(nil)
+	task := NewFromInterface(ch, m)
+	err := task.Run(context.Background())
+	r.Equal(ErrHeartbeatTimeout, err)
+	r.Equal([]error{ErrHeartbeatTimeout, ErrHeartbeatTimeout}, causes)
+	r.Equal(2, task.GetFallNumber())
+	r.True(task.state.IsFailed())
+	m.AssertNumberOfCalls(t, "Shutdown", 2)
+}
+
+func TestTask_Run_Heartbeat(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.HeartbeatTimeout = 20 * time.Millisecond
+	m.On("GetTaskConfig").Return(cfg)
+	m.On("Run", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		ctx := args.Get(0).(context.Context)
+		for i := 0; i < 10; i++ {
+			time.Sleep(5 * time.Millisecond)
+			Heartbeat(ctx)
+		}
+	})
+	task := NewFromInterface(ch, m)
+	r.NoError(task.Run(context.Background()))
+	m.AssertNotCalled(t, "Shutdown", mock.Anything)
+}
+
+func TestHeartbeat_ForeignContext(t *testing.T) {
+	require.NotPanics(t, func() {
+		Heartbeat(context.Background())
+	})
+}
+
+func TestTask_Run_AttemptContext(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = 1
+	m.On("GetTaskConfig").Return(cfg)
+	var contexts []context.Context
+	m.On("Run", mock.Anything).Return(errors.New("expected error")).Once().Run(func(args mock.Arguments) {
+		contexts = append(contexts, args.Get(0).(context.Context))
+	})
+	m.On("Run", mock.Anything).Return(nil).Once().Run(func(args mock.Arguments) {
+		contexts = append(contexts, args.Get(0).(context.Context))
+		// context of previous attempt is canceled, context of current one isn't
+		r.Error(contexts[0].Err())
+		r.NoError(contexts[1].Err())
+	})
+	task := NewFromInterface(ch, m)
+	r.NoError(task.Run(context.Background()))
+	r.Len(contexts, 2)
+	for _, ctx := range contexts {
+		r.Error(ctx.Err())
+		r.Equal(ErrAttemptFinished, context.Cause(ctx))
+	}
+}
+
+func TestTask_Shutdown_CancelAttempt(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = -1
+	m.On("GetTaskConfig").Return(cfg)
+	causes := make(chan error, 2)
+	m.On("Run", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		// task stops only by cancel of context
+		ctx := args.Get(0).(context.Context)
+		<-ctx.Done()
+		causes <- context.Cause(ctx)
+	})
+	m.On("Shutdown", mock.Anything).Return(nil)
+	task := NewFromInterface(ch, m)
+	resCh := make(chan error)
+	go func() {
+		resCh <- task.Run(context.Background())
+	}()
+
+	waitRunning := func() {
+		for !task.state.IsRunning() {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitRunning()
+	r.NoError(task.Restart(context.Background()))
+	r.Equal(ErrTaskRestart, <-causes)
+	waitRunning()
+	r.NoError(task.Shutdown(context.Background()))
+	r.Equal(ErrTaskShutdown, <-causes)
+	select {
+	case err := <-resCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("task isn't stopped")
+	}
+}
+
+func TestTask_Run_Status(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = 1
+	m.On("GetTaskConfig").Return(cfg)
+	statuses := make([]Status, 0)
+	task := NewFromInterface(ch, m)
+	m.On("Run", mock.Anything).Return(errors.New("expected error")).Once().Run(func(mock.Arguments) {
+		statuses = append(statuses, task.state.GetStatus())
+	})
+	m.On("Run", mock.Anything).Return(nil).Once().Run(func(mock.Arguments) {
+		statuses = append(statuses, task.state.GetStatus())
+	})
+	r.Equal(StatusPending, task.state.GetStatus())
+	r.NoError(task.Run(context.Background()))
+	r.Equal([]Status{StatusRunning, StatusRunning}, statuses)
+	snapshot := task.state.Snapshot()
+	r.Equal(StatusStopped, snapshot.Status)
+	for _, status := range []Status{StatusPending, StatusStarting, StatusRunning, StatusRestarting, StatusStopped} {
+		r.Contains(snapshot.Transitions, status)
+	}
+	r.NotContains(snapshot.Transitions, StatusFailed)
+}
+
+func TestTask_Shutdown_Status(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	m.On("GetTaskConfig").Return(GetDefaultConfig())
+	stopCh := make(chan struct{})
+	m.On("Run", mock.Anything).Return(nil).Run(func(mock.Arguments) {
+		<-stopCh
+	})
+	task := NewFromInterface(ch, m)
+	m.On("Shutdown", mock.Anything).Return(nil).Run(func(mock.Arguments) {
+		// ready task can't become running again while it's stopping
+		task.setReady()
+		r.Equal(StatusStopping, task.state.GetStatus())
+		close(stopCh)
+	})
+	resCh := make(chan error)
+	go func() {
+		resCh <- task.Run(context.Background())
+	}()
+	for !task.state.IsRunning() {
+		time.Sleep(time.Millisecond)
+	}
+	r.NoError(task.Shutdown(context.Background()))
+	r.NoError(<-resCh)
+	r.Equal(StatusStopped, task.state.GetStatus())
+}
+
+func TestTask_Run_ShutdownWhileWaitingRestart(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = -1
+	cfg.RestartTimeout = time.Hour
+	m.On("GetTaskConfig").Return(cfg)
+	m.On("Run", mock.Anything).Return(errors.New("expected error"))
+	m.On("Shutdown", mock.Anything).Return(nil)
+	task := NewFromInterface(ch, m)
+	resCh := make(chan error)
+	go func() {
+		resCh <- task.Run(context.Background())
+	}()
+	for task.state.GetStatus() != StatusWaitingRestart {
+		time.Sleep(time.Millisecond)
+	}
+	r.WithinDuration(time.Now().Add(time.Hour), task.state.Snapshot().RestartAt, time.Second)
+
+	r.NoError(task.Shutdown(context.Background()))
+	select {
+	case err := <-resCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("waiting of restart isn't interrupted")
+	}
+	r.Equal(StatusStopped, task.state.GetStatus())
+	m.AssertNumberOfCalls(t, "Run", 1)
+}
+
+func TestTask_Run_ContextDoneWhileWaitingRestart(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = -1
+	cfg.RestartTimeout = time.Hour
+	m.On("GetTaskConfig").Return(cfg)
+	m.On("Run", mock.Anything).Return(errors.New("expected error"))
+	task := NewFromInterface(ch, m)
+	expectedCause := errors.New("expected cause")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	resCh := make(chan error)
+	go func() {
+		resCh <- task.Run(ctx)
+	}()
+	for task.state.GetStatus() != StatusWaitingRestart {
+		time.Sleep(time.Millisecond)
+	}
+	cancel(expectedCause)
+	select {
+	case err := <-resCh:
+		r.Equal(expectedCause, err)
+	case <-time.After(time.Second):
+		r.Fail("waiting of restart isn't interrupted")
+	}
+	m.AssertNumberOfCalls(t, "Run", 1)
+}
+
+func TestTask_Run_ContextDoneWithoutRestartDelay(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = 3
+	m.On("GetTaskConfig").Return(cfg)
+	m.On("Run", mock.Anything).Return(errors.New("expected error"))
+	task := NewFromInterface(ch, m)
+	expectedCause := errors.New("expected cause")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(expectedCause)
+	r.Equal(expectedCause, task.Run(ctx))
+	m.AssertNumberOfCalls(t, "Run", 1)
+	r.False(task.state.IsFailed())
+	r.Equal(StatusStopped, task.state.GetStatus())
+}
+
+func TestTask_Run_CompletionRestart(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.CompletionPolicy = CompletionRestart
+	cfg.RestartTimeout = time.Millisecond
+	m.On("GetTaskConfig").Return(cfg)
+	task := NewFromInterface(ch, m)
+	runCount := 0
+	m.On("Run", mock.Anything).Return(nil).Run(func(mock.Arguments) {
+		runCount++
+		if runCount == 3 {
+			task.state.SetShutdownRequested()
+		}
+	})
+	r.NoError(task.Run(context.Background()))
+	r.Equal(3, runCount)
+	r.Equal(0, task.GetFallNumber())
+	r.False(task.IsCompleted())
+}
+
+func TestTask_IsCompleted(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	m.On("GetTaskConfig").Return(GetDefaultConfig())
+	m.On("Run", mock.Anything).Return(nil)
+	task := NewFromInterface(ch, m)
+	r.False(task.IsCompleted())
+	r.NoError(task.Run(context.Background()))
+	r.True(task.IsCompleted())
+	r.Equal(CompletionIgnore, task.GetCompletionPolicy())
+}
+
+func TestTask_Run_Events(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = 1
+	cfg.RestartTimeout = time.Millisecond
+	m.On("GetTaskConfig").Return(cfg)
+	eErr := errors.New("expected err")
+	m.On("Run", mock.Anything).Return(eErr)
+	task := NewFromInterface(ch, m)
+	events := make([]Event, 0)
+	task.SetListener(func(event Event) {
+		r.Equal("expectedID", event.ID)
+		r.False(event.Time.IsZero())
+		event.Time = time.Time{}
+		event.ID = ""
+		events = append(events, event)
+	})
+	err := task.Run(context.Background())
+	r.Equal(eErr, err)
+	r.Equal([]Event{
+		{Type: EventStarting},
+		{Type: EventReady},
+		{Type: EventFailed, FallNumber: 1, Err: eErr},
+		{Type: EventRestarting, FallNumber: 1, Err: eErr, Delay: time.Millisecond},
+		{Type: EventStarting, FallNumber: 1},
+		{Type: EventReady, FallNumber: 1},
+		{Type: EventFailed, FallNumber: 2, Err: eErr},
+		{Type: EventStopped, FallNumber: 2, Err: eErr},
+	}, events)
+}
+
+func TestTask_Tracing(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = 1
+	cfg.RestartTimeout = time.Millisecond
+	m.On("GetTaskConfig").Return(cfg)
+	eErr := errors.New("expected err")
+	m.On("Run", mock.Anything).Return(eErr).Once()
+	m.On("Run", mock.Anything).Return(nil)
+	m.On("Shutdown", mock.Anything).Return(nil)
+	tracer := trace.NewInMemoryTracer()
+	task := NewFromInterface(ch, m)
+	task.SetTracer(tracer)
+	r.NoError(task.Run(context.Background()))
+	r.NoError(task.Shutdown(context.Background()))
+
+	spans := tracer.Spans()
+	r.Len(spans, 4)
+	r.Equal("task.attempt", spans[0].Name)
+	r.Equal(map[string]interface{}{
+		trace.KeyTaskID:  "expectedID",
+		trace.KeyAttempt: 1,
+	}, spans[0].Attributes)
+	r.Equal(eErr, spans[0].Err)
+	r.Equal("task.restart_wait", spans[1].Name)
+	r.Equal(map[string]interface{}{
+		trace.KeyTaskID:       "expectedID",
+		trace.KeyAttempt:      1,
+		trace.KeyFallNumber:   1,
+		trace.KeyRestartDelay: int64(time.Millisecond),
+	}, spans[1].Attributes)
+	r.Equal("task.attempt", spans[2].Name)
+	r.Equal(2, spans[2].Attributes[trace.KeyAttempt])
+	r.NoError(spans[2].Err)
+	r.Equal("task.shutdown", spans[3].Name)
+	r.Equal(map[string]interface{}{
+		trace.KeyTaskID:          "expectedID",
+		trace.KeyShutdownReason:  "shutdown",
+		trace.KeyShutdownOutcome: "ok",
+	}, spans[3].Attributes)
+}
+
+type ReloaderMock struct {
+	TaskMock
+}
+
+func (m *ReloaderMock) Reload(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func TestTask_Reload(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	m.On("GetTaskConfig").Return(GetDefaultConfig())
+	task := NewFromInterface(ch, m)
+	r.False(task.IsReloadable())
+	r.Equal(ErrReloadNotSupported, task.Reload(context.Background()))
+
+	eErr := errors.New("expected err")
+	rm := &ReloaderMock{}
+	rm.On("GetID").Return("expectedID")
+	rm.On("GetTaskConfig").Return(GetDefaultConfig())
+	rm.On("Reload", mock.Anything).Return(eErr)
+	tracer := trace.NewInMemoryTracer()
+	task = NewFromInterface(ch, rm)
+	task.SetTracer(tracer)
+	r.True(task.IsReloadable())
+	r.Equal(eErr, task.Reload(context.Background()))
+	rm.AssertNumberOfCalls(t, "Reload", 1)
+
+	spans := tracer.Spans()
+	r.Len(spans, 1)
+	r.Equal("task.reload", spans[0].Name)
+	r.Equal(eErr, spans[0].Err)
+}
+
+func TestTask_Restart_WhileWaitingRestart(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = 1
+	cfg.RestartTimeout = 50 * time.Millisecond
+	m.On("GetTaskConfig").Return(cfg)
+	eErr := errors.New("expected error")
+	m.On("Run", mock.Anything).Return(eErr)
+	task := NewFromInterface(ch, m)
+	resCh := make(chan error)
+	go func() {
+		resCh <- task.Run(context.Background())
+	}()
+	for task.state.GetStatus() != StatusWaitingRestart {
+		time.Sleep(time.Millisecond)
+	}
+	// restart by supervisor after fall of sibling isn't applied to the next attempt
+	r.NoError(task.Restart(context.Background()))
+	select {
+	case err := <-resCh:
+		r.Equal(eErr, err)
+	case <-time.After(time.Second):
+		r.Fail("task isn't failed")
+	}
+	r.True(task.state.IsFailed())
+	r.Equal(2, task.GetFallNumber())
+	m.AssertNumberOfCalls(t, "Run", 2)
+	m.AssertNotCalled(t, "Shutdown", mock.Anything)
+}
+
+func TestTask_Run_ErrorAfterShutdown(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	cfg := GetDefaultConfig()
+	cfg.FallNumber = -1
+	m.On("GetTaskConfig").Return(cfg)
+	stopCh := make(chan struct{})
+	eErr := errors.New("expected err")
+	m.On("Run", mock.Anything).Return(eErr).Run(func(mock.Arguments) {
+		<-stopCh
+	})
+	m.On("Shutdown", mock.Anything).Return(nil).Run(func(mock.Arguments) {
+		close(stopCh)
+	})
+	task := NewFromInterface(ch, m)
+	var mu sync.Mutex
+	eventTypes := make([]EventType, 0)
+	task.SetListener(func(event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		eventTypes = append(eventTypes, event.Type)
+	})
+	resCh := make(chan error)
+	go func() {
+		resCh <- task.Run(context.Background())
+	}()
+	for task.state.GetStatus() != StatusRunning {
+		time.Sleep(time.Millisecond)
+	}
+	r.NoError(task.Shutdown(context.Background()))
+	r.Equal(eErr, <-resCh)
+
+	// error after shutdown request isn't counted like fall and task isn't restarted
+	r.Equal(StatusStopped, task.state.GetStatus())
+	r.Equal(0, task.GetFallNumber())
+	r.Len(ch, 0)
+	m.AssertNumberOfCalls(t, "Run", 1)
+	mu.Lock()
+	defer mu.Unlock()
+	r.Equal([]EventType{EventStarting, EventReady, EventStopped}, eventTypes)
+}
+
+func TestTask_Run_NotStartedWhileStopping(t *testing.T) {
+	r := require.New(t)
+
+	ch := make(chan Err, 10)
+	m := &TaskMock{}
+	m.On("GetID").Return("expectedID")
+	m.On("GetTaskConfig").Return(GetDefaultConfig())
+	task := NewFromInterface(ch, m)
+	r.NoError(task.state.SetStatus(StatusStopping))
+	err := task.runAttempt(context.Background(), 1)
+	r.True(errors.Is(err, ErrInvalidTransition))
+	r.False(task.setRestarting())
+	r.Equal(StatusStopping, task.state.GetStatus())
+	m.AssertNotCalled(t, "Run", mock.Anything)
+}
@@ -0,0 +1,185 @@
+package gomultitask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+var (
+	// ErrTaskExists is returned when task with the same id is already in operator
+	ErrTaskExists = errors.New("task already exists")
+	// ErrTaskNotFound is returned when task with id isn't in operator
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrTaskHasDependents is returned on remove of task on which other tasks depend
+	ErrTaskHasDependents = errors.New("task has dependents")
+	// ErrOperatorStopping is returned when tasks are changed after start of shutdown
+	ErrOperatorStopping = errors.New("operator is stopping")
+)
+
+// taskRun is run of task started by operator
+type taskRun struct {
+	// cancel stops waiting of dependencies
+	cancel context.CancelFunc
+	// done is closed when Run of task is returned
+	done chan struct{}
+}
+
+// AddTask add task to operator, task is started at once if operator is running,
+// id of task must be unique, dependencies of task must be in operator
+func (o *Operator) AddTask(i task.Interface) error {
+	t := task.NewFromInterface(o.notHandledErr, i)
+	t.SetListener(o.handleTaskEvent)
+	if o.tracer != nil {
+		t.SetTracer(o.tracer)
+	}
+
+	o.tasksMu.Lock()
+	defer o.tasksMu.Unlock()
+	if o.stopping {
+		return ErrOperatorStopping
+	}
+	for _, existing := range o.tasks {
+		if existing.GetID() == t.GetID() {
+			return fmt.Errorf("task ID %s: %w", t.GetID(), ErrTaskExists)
+		}
+	}
+	taskList, err := sortByDependencies(append(o.getTaskListLocked(), t))
+	if err != nil {
+		return err
+	}
+	o.tasks = taskList
+	if o.runCtx != nil {
+		o.logInfo("Task added", LogKeyTaskID, t.GetID())
+		o.startTask(o.runCtx, t)
+	}
+	return nil
+}
+
+// RemoveTask gracefully stop task and remove it from operator,
+// it waits while Run of task is returned or ctx is done, task can't be removed while other tasks depend on it
+func (o *Operator) RemoveTask(ctx context.Context, id string) error {
+	o.tasksMu.Lock()
+	if o.stopping {
+		o.tasksMu.Unlock()
+		return ErrOperatorStopping
+	}
+	idx := -1
+	dependents := make([]string, 0)
+	for i, t := range o.tasks {
+		if t.GetID() == id {
+			idx = i
+			continue
+		}
+		for _, depID := range t.GetDependsOn() {
+			if depID == id {
+				dependents = append(dependents, t.GetID())
+			}
+		}
+	}
+	if idx < 0 {
+		o.tasksMu.Unlock()
+		return fmt.Errorf("task ID %s: %w", id, ErrTaskNotFound)
+	}
+	if len(dependents) > 0 {
+		o.tasksMu.Unlock()
+		return fmt.Errorf("task ID %s is dependency of %s: %w", id, dependents, ErrTaskHasDependents)
+	}
+	t := o.tasks[idx]
+	taskList := make([]*task.Task, 0, len(o.tasks)-1)
+	taskList = append(taskList, o.tasks[:idx]...)
+	o.tasks = append(taskList, o.tasks[idx+1:]...)
+	run := o.taskRuns[t]
+	delete(o.taskRuns, t)
+	o.tasksMu.Unlock()
+
+	if run == nil {
+		return nil
+	}
+	o.logInfo("Task removed", LogKeyTaskID, id)
+	run.cancel()
+	if err := t.Shutdown(ctx); err != nil {
+		return err
+	}
+	select {
+	case <-run.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startTask run task after its dependencies, fail of task stops operator if task isn't removed,
+// completion of task is handled by operator according to completion policy,
+// it must be called under lock of tasks
+func (o *Operator) startTask(ctx context.Context, t *task.Task) {
+	waitCtx, cancel := context.WithCancel(ctx)
+	run := &taskRun{cancel: cancel, done: make(chan struct{})}
+	o.taskRuns[t] = run
+	go func() {
+		defer close(run.done)
+		defer cancel()
+		if !o.waitDependencies(waitCtx, t) {
+			return
+		}
+		err := t.Run(ctx)
+		switch {
+		case !o.hasTask(t):
+		case err != nil:
+			select {
+			case o.errCh <- task.Err{ID: t.GetID(), FallNumber: t.GetFallNumber(), Err: err}:
+			case <-ctx.Done():
+			}
+		case t.IsCompleted():
+			select {
+			case o.completedCh <- t:
+			case <-ctx.Done():
+			}
+		}
+	}()
+}
+
+// getTaskList return copy of tasks list, it's safe for concurrent use
+func (o *Operator) getTaskList() []*task.Task {
+	o.tasksMu.RLock()
+	defer o.tasksMu.RUnlock()
+	return o.getTaskListLocked()
+}
+
+func (o *Operator) getTaskListLocked() []*task.Task {
+	return append([]*task.Task(nil), o.tasks...)
+}
+
+// allTasksCompleted return true if all tasks completed successfully
+func (o *Operator) allTasksCompleted() bool {
+	for _, t := range o.getTaskList() {
+		if !t.IsCompleted() {
+			return false
+		}
+	}
+	return true
+}
+
+func (o *Operator) hasTaskID(id string) bool {
+	o.tasksMu.RLock()
+	defer o.tasksMu.RUnlock()
+	for _, existing := range o.tasks {
+		if existing.GetID() == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Operator) hasTask(t *task.Task) bool {
+	o.tasksMu.RLock()
+	defer o.tasksMu.RUnlock()
+	for _, existing := range o.tasks {
+		if existing == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package gomultitask
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/gomultitask/task"
+)
+
+func TestOperator_AddTask(t *testing.T) {
+	r := require.New(t)
+
+	first := NewRestartableTask("first", task.Config{})
+	op := NewOperator(first).WithShutdownSignals(nil)
+	r.NoError(op.AddTask(NewRestartableTask("before_run", task.Config{})))
+
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	waitFor(t, op.IsReady)
+
+	added := NewRestartableTask("added", task.Config{DependsOn: []string{"first"}})
+	r.NoError(op.AddTask(added))
+	waitFor(t, func() bool {
+		return added.getRunCount() == 1
+	})
+	r.Equal([]string{"first", "before_run", "added"}, getIDs(op.getTaskList()))
+
+	r.True(errors.Is(op.AddTask(NewRestartableTask("added", task.Config{})), ErrTaskExists))
+	r.True(errors.Is(
+		op.AddTask(NewRestartableTask("unknown_dep", task.Config{DependsOn: []string{"unknown"}})),
+		ErrUnknownDependency,
+	))
+
+	// added task is supervised like others
+	added.failCh <- errors.New("expected error")
+	select {
+	case err := <-tCh:
+		var stopErr *StopError
+		r.True(errors.As(err, &stopErr))
+		r.Equal("added", stopErr.TaskID)
+	case <-time.After(time.Second):
+		r.Fail("operator isn't stopped")
+	}
+	r.True(errors.Is(op.AddTask(NewRestartableTask("late", task.Config{})), ErrOperatorStopping))
+}
+
+func TestOperator_RemoveTask(t *testing.T) {
+	r := require.New(t)
+
+	first := NewRestartableTask("first", task.Config{})
+	dependent := NewRestartableTask("dependent", task.Config{DependsOn: []string{"first"}})
+	second := NewRestartableTask("second", task.Config{})
+	op := NewOperator(first, dependent, second).WithShutdownSignals(nil)
+
+	tCh := make(chan error)
+	go func() {
+		tCh <- op.Run(context.Background())
+	}()
+	waitFor(t, op.IsReady)
+
+	r.True(errors.Is(op.RemoveTask(context.Background(), "unknown"), ErrTaskNotFound))
+	r.True(errors.Is(op.RemoveTask(context.Background(), "first"), ErrTaskHasDependents))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	r.NoError(op.RemoveTask(ctx, "second"))
+	r.Equal([]string{"first", "dependent"}, getIDs(op.getTaskList()))
+	r.NoError(op.RemoveTask(ctx, "dependent"))
+	r.NoError(op.RemoveTask(ctx, "first"))
+	r.Empty(op.getTaskList())
+
+	// operator isn't stopped by removed tasks
+	select {
+	case err := <-tCh:
+		r.Failf("operator is stopped", "err: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	r.NoError(op.Shutdown(ctx))
+	select {
+	case err := <-tCh:
+		r.NoError(err)
+	case <-time.After(time.Second):
+		r.Fail("operator isn't stopped")
+	}
+	r.True(errors.Is(op.RemoveTask(ctx, "first"), ErrOperatorStopping))
+}
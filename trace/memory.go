@@ -0,0 +1,118 @@
+package trace
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SpanData is ended span recorded by InMemoryTracer
+type SpanData struct {
+	ID         int64
+	ParentID   int64
+	Name       string
+	Attributes map[string]interface{}
+	Err        error
+	StartTime  time.Time
+	EndTime    time.Time
+}
+
+// InMemoryTracer records ended spans in memory, it's useful for tests
+type InMemoryTracer struct {
+	mu     sync.Mutex
+	nextID int64
+	spans  []SpanData
+}
+
+// NewInMemoryTracer build tracer without spans
+func NewInMemoryTracer() *InMemoryTracer {
+	return &InMemoryTracer{}
+}
+
+type spanKey struct{}
+
+// Start implements Tracer
+func (t *InMemoryTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	t.mu.Lock()
+	t.nextID++
+	id := t.nextID
+	t.mu.Unlock()
+
+	var parentID int64
+	if parent, ok := ctx.Value(spanKey{}).(*memorySpan); ok {
+		parentID = parent.data.ID
+	}
+	span := &memorySpan{
+		tracer: t,
+		data: SpanData{
+			ID:         id,
+			ParentID:   parentID,
+			Name:       name,
+			Attributes: make(map[string]interface{}),
+			StartTime:  time.Now(),
+		},
+	}
+	span.SetAttributes(attrs...)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Spans return copy of ended spans in order of end
+func (t *InMemoryTracer) Spans() []SpanData {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]SpanData(nil), t.spans...)
+}
+
+// Reset forget recorded spans
+func (t *InMemoryTracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = nil
+}
+
+func (t *InMemoryTracer) record(data SpanData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = append(t.spans, data)
+}
+
+type memorySpan struct {
+	tracer *InMemoryTracer
+	mu     sync.Mutex
+	ended  bool
+	data   SpanData
+}
+
+func (s *memorySpan) SetAttributes(attrs ...Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, attr := range attrs {
+		s.data.Attributes[attr.Key] = attr.Value
+	}
+}
+
+func (s *memorySpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Err = err
+}
+
+func (s *memorySpan) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := s.data
+	data.Attributes = make(map[string]interface{}, len(s.data.Attributes))
+	for k, v := range s.data.Attributes {
+		data.Attributes[k] = v
+	}
+	s.mu.Unlock()
+	s.tracer.record(data)
+}
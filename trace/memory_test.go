@@ -0,0 +1,41 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryTracer(t *testing.T) {
+	r := require.New(t)
+
+	tracer := NewInMemoryTracer()
+	ctx, parent := tracer.Start(context.Background(), "parent", String(KeyTaskID, "first"))
+	_, child := tracer.Start(ctx, "child")
+	r.Empty(tracer.Spans())
+
+	eErr := errors.New("expected error")
+	child.SetAttributes(Int(KeyAttempt, 2))
+	child.RecordError(eErr)
+	child.RecordError(nil)
+	child.End()
+	child.End()
+	parent.End()
+
+	spans := tracer.Spans()
+	r.Len(spans, 2)
+	r.Equal("child", spans[0].Name)
+	r.Equal(spans[1].ID, spans[0].ParentID)
+	r.Equal(map[string]interface{}{KeyAttempt: 2}, spans[0].Attributes)
+	r.Equal(eErr, spans[0].Err)
+	r.False(spans[0].EndTime.Before(spans[0].StartTime))
+	r.Equal("parent", spans[1].Name)
+	r.Equal(int64(0), spans[1].ParentID)
+	r.Equal(map[string]interface{}{KeyTaskID: "first"}, spans[1].Attributes)
+	r.NoError(spans[1].Err)
+
+	tracer.Reset()
+	r.Empty(tracer.Spans())
+}
@@ -0,0 +1,70 @@
+// Package trace contains minimal tracing API which is used for spans around lifecycle of tasks,
+// it's easy to adapt it to OpenTelemetry or another tracing system
+package trace
+
+import (
+	"context"
+)
+
+// Attribute keys used in spans of tasks
+const (
+	KeyTaskID          = "task.id"
+	KeyAttempt         = "task.attempt"
+	KeyFallNumber      = "task.fall_number"
+	KeyRestartDelay    = "task.restart_delay"
+	KeyShutdownReason  = "task.shutdown.reason"
+	KeyShutdownOutcome = "task.shutdown.outcome"
+)
+
+// Attribute of span
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String build string attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int build int attribute
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int64 build int64 attribute
+func Int64(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Tracer starts spans
+type Tracer interface {
+	// Start span, returned context contains span, so spans started with it are children of the span
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is traced operation
+type Span interface {
+	// SetAttributes add attributes to span
+	SetAttributes(attrs ...Attribute)
+	// RecordError register error of operation, nil is ignored
+	RecordError(err error)
+	// End span
+	End()
+}
+
+// NoopTracer doesn't record anything, it's default tracer
+type NoopTracer struct{}
+
+// Start implements Tracer
+func (NoopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+
+func (noopSpan) RecordError(error) {}
+
+func (noopSpan) End() {}
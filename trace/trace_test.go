@@ -0,0 +1,30 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttributes(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal(Attribute{Key: "key", Value: "value"}, String("key", "value"))
+	r.Equal(Attribute{Key: "key", Value: 1}, Int("key", 1))
+	r.Equal(Attribute{Key: "key", Value: int64(1)}, Int64("key", 1))
+}
+
+func TestNoopTracer(t *testing.T) {
+	r := require.New(t)
+
+	ctx := context.Background()
+	spanCtx, span := NoopTracer{}.Start(ctx, "span", String("key", "value"))
+	r.Equal(ctx, spanCtx)
+	r.NotPanics(func() {
+		span.SetAttributes(Int("key", 1))
+		span.RecordError(errors.New("expected error"))
+		span.End()
+	})
+}